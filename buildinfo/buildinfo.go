@@ -0,0 +1,93 @@
+package buildinfo
+
+import (
+	"os"
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
+
+const unknown = "unknown"
+
+type Info struct {
+	Path      string // main module path
+	Version   string // main module version, "(devel)" for local builds
+	Revision  string // vcs revision
+	Time      string // vcs commit time
+	Modified  bool   // vcs working tree had local changes
+	GoVersion string
+}
+
+var (
+	info Info
+	once sync.Once
+)
+
+// Get returns information about the running binary read from debug.ReadBuildInfo
+func Get() Info {
+	once.Do(func() {
+		info = read()
+	})
+
+	return info
+}
+
+func read() Info {
+	i := Info{
+		Path:      unknown,
+		Version:   unknown,
+		Revision:  unknown,
+		Time:      unknown,
+		GoVersion: runtime.Version(),
+	}
+
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return i
+	}
+
+	if bi.Main.Path != "" {
+		i.Path = bi.Main.Path
+	}
+	if bi.Main.Version != "" {
+		i.Version = bi.Main.Version
+	}
+	if bi.GoVersion != "" {
+		i.GoVersion = bi.GoVersion
+	}
+
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			i.Revision = s.Value
+		case "vcs.time":
+			i.Time = s.Value
+		case "vcs.modified":
+			i.Modified = s.Value == "true"
+		}
+	}
+
+	return i
+}
+
+// ShortRevision returns first 12 symbols of the vcs revision
+func (i Info) ShortRevision() string {
+	if len(i.Revision) > 12 {
+		return i.Revision[:12]
+	}
+
+	return i.Revision
+}
+
+// Hostname returns os.Hostname or "unknown" on error
+func Hostname() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return unknown
+	}
+
+	return hostname
+}
+
+// Env returns the deployment environment from the ENV variable
+func Env() string { return os.Getenv("ENV") }
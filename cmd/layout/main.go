@@ -0,0 +1,35 @@
+// Command layout validates, prints and compares configs of the registered types
+// without starting the service. Services register their own config types
+// in a copy of this command, see configloader.Register.
+package main
+
+import (
+	"github.com/timmbarton/layout/components/adminserver"
+	"github.com/timmbarton/layout/components/grpcserver"
+	"github.com/timmbarton/layout/components/httpserver"
+	"github.com/timmbarton/layout/components/jaeger"
+	"github.com/timmbarton/layout/components/pidfile"
+	"github.com/timmbarton/layout/components/postgresconn"
+	"github.com/timmbarton/layout/components/redisconn"
+	"github.com/timmbarton/layout/components/signoz"
+	"github.com/timmbarton/layout/configloader"
+	"github.com/timmbarton/layout/configloader/cli"
+	"github.com/timmbarton/layout/log"
+	"github.com/timmbarton/layout/template"
+)
+
+func main() {
+	configloader.Register("admin", func() any { return new(adminserver.Config) })
+	configloader.Register("app", func() any { return new(template.Config) })
+	configloader.Register("grpc", func() any { return new(grpcserver.DefaultServerConfig) })
+	configloader.Register("http", func() any { return new(httpserver.Config) })
+	configloader.Register("jaeger", func() any { return new(jaeger.Config) })
+	configloader.Register("log", func() any { return new(log.Config) })
+	configloader.Register("pidfile", func() any { return new(pidfile.Config) })
+	configloader.Register("postgres", func() any { return new(postgresconn.Config) })
+	configloader.Register("redis", func() any { return new(redisconn.Config) })
+	configloader.Register("service", func() any { return new(template.StandardConfig) })
+	configloader.Register("signoz", func() any { return new(signoz.Config) })
+
+	cli.Main()
+}
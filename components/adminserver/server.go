@@ -0,0 +1,83 @@
+package adminserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/timmbarton/utils/types/secs"
+
+	"github.com/timmbarton/layout/log"
+)
+
+// LevelsPath serves the levels of named loggers, see log.LevelsHandler
+const LevelsPath = "/log/levels"
+
+// Config of the internal HTTP server for debugging endpoints,
+// it must not be exposed outside the cluster
+type Config struct {
+	StartTimeout secs.Seconds `validate:"seconds" default:"1s"`
+	StopTimeout  secs.Seconds `validate:"seconds" default:"10s"`
+
+	Addr string `validate:"required" default:"127.0.0.1:8081"` // loopback by default, e.g. ":8081" listens on all interfaces
+}
+
+type Server struct {
+	cfg    Config
+	mux    *http.ServeMux
+	server *http.Server
+}
+
+func New(cfg Config) *Server {
+	mux := http.NewServeMux()
+	mux.Handle(LevelsPath, log.LevelsHandler())
+
+	return &Server{
+		cfg: cfg,
+		mux: mux,
+		server: &http.Server{
+			Addr:              cfg.Addr,
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		},
+	}
+}
+
+// Handle registers the handler for the pattern, see http.ServeMux
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+func (s *Server) Start(_ context.Context) error {
+	listener, err := net.Listen("tcp", s.cfg.Addr)
+	if err != nil {
+		return err
+	}
+
+	errCh := make(chan error, 1)
+
+	go func() {
+		err := s.server.Serve(listener)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+		}
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-time.After(time.Duration(s.cfg.StartTimeout)):
+		return nil
+	}
+}
+func (s *Server) Stop(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(s.cfg.StopTimeout))
+	defer cancel()
+
+	return s.server.Shutdown(ctx)
+}
+func (s *Server) GetName() string { return fmt.Sprintf("Admin HTTP Server at %s", s.cfg.Addr) }
+func (s *Server) GetAddr() string { return s.cfg.Addr }
@@ -18,15 +18,15 @@ import (
 )
 
 type DefaultServerConfig struct {
-	StartTimeout secs.Seconds `validate:"seconds"`
-	StopTimeout  secs.Seconds `validate:"seconds"`
+	StartTimeout secs.Seconds `validate:"seconds" default:"1s"`
+	StopTimeout  secs.Seconds `validate:"seconds" default:"30s"`
 
 	Host              string       `validate:"required,min=1"`
 	ServiceId         int          `validate:"required,min=10,max=99"`
 	MaxConnectionIdle secs.Seconds `validate:"seconds"`
-	Timeout           secs.Seconds `validate:"seconds"`
+	Timeout           secs.Seconds `validate:"seconds" default:"20s"`
 	MaxConnectionAge  secs.Seconds `validate:"seconds"`
-	Time              secs.Seconds `validate:"seconds"`
+	Time              secs.Seconds `validate:"seconds" default:"2h"`
 
 	DisableReflection bool
 	DisableLogging    bool
@@ -42,6 +42,7 @@ func (s *DefaultServer) Init(cfg DefaultServerConfig) {
 	s.cfg = cfg
 
 	interceptors := []grpc.UnaryServerInterceptor{
+		GetLogContextInterceptor(),
 		errs.GetGRPCInterceptor(s.cfg.ServiceId),
 	}
 
@@ -58,6 +59,7 @@ func (s *DefaultServer) Init(cfg DefaultServerConfig) {
 		}),
 		grpc.StatsHandler(otelgrpc.NewServerHandler()),
 		grpc.ChainUnaryInterceptor(interceptors...),
+		grpc.ChainStreamInterceptor(GetLogContextStreamInterceptor()),
 	)
 }
 
@@ -109,3 +111,4 @@ func (s *DefaultServer) Stop(_ context.Context) error {
 	}
 }
 func (s *DefaultServer) GetName() string { return fmt.Sprintf("GRPC Server at %s", s.cfg.Host) }
+func (s *DefaultServer) GetAddr() string { return s.cfg.Host }
@@ -0,0 +1,59 @@
+package grpcserver
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/timmbarton/layout/log"
+)
+
+const RequestIdMetadataKey = "x-request-id"
+
+// GetLogContextInterceptor attaches the request id, method and peer address to the context,
+// so that every entry logged with it includes them, see log.WithContext.
+// The request id is taken from the x-request-id metadata or generated if it is missing or invalid,
+// see log.IsValidRequestId, and returned in the header.
+func GetLogContextInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(logContext(ctx, info.FullMethod), req)
+	}
+}
+
+// GetLogContextStreamInterceptor is GetLogContextInterceptor for streams
+func GetLogContextStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &contextStream{ServerStream: ss, ctx: logContext(ss.Context(), info.FullMethod)})
+	}
+}
+
+func logContext(ctx context.Context, method string) context.Context {
+	requestId := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(RequestIdMetadataKey); len(values) > 0 {
+			requestId = values[0]
+		}
+	}
+	if !log.IsValidRequestId(requestId) {
+		requestId = log.NewRequestId()
+	}
+
+	_ = grpc.SetHeader(ctx, metadata.Pairs(RequestIdMetadataKey, requestId))
+
+	fields := []zap.Field{zap.String("method", method)}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		fields = append(fields, zap.String("ip", p.Addr.String()))
+	}
+
+	return log.WithContext(log.WithRequestId(ctx, requestId), fields...)
+}
+
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context { return s.ctx }
@@ -12,8 +12,8 @@ import (
 )
 
 type Config struct {
-	StartTimeout secs.Seconds `validate:"seconds"`
-	StopTimeout  secs.Seconds `validate:"seconds"`
+	StartTimeout secs.Seconds `validate:"seconds" default:"1s"`
+	StopTimeout  secs.Seconds `validate:"seconds" default:"30s"`
 
 	Addr                        string `validate:"required"`
 	ServiceId                   int    `validate:"required,min=10,max=99"`
@@ -46,6 +46,7 @@ func (s *DefaultServer) Init(cfg Config, bind func(fiber.Router)) {
 			},
 		),
 	)
+	s.fiber.Use(GetLogContextMiddleware())
 	s.fiber.Use(GetErrsMiddleware(cfg.ServiceId, cfg.ShowUnknownErrorsInResponse, cfg.Logger))
 
 	bind(s.fiber)
@@ -88,3 +89,4 @@ func (s *DefaultServer) Stop(_ context.Context) error {
 }
 
 func (s *DefaultServer) GetName() string { return "HTTP Server" }
+func (s *DefaultServer) GetAddr() string { return s.cfg.Addr }
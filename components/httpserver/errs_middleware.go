@@ -49,6 +49,8 @@ func getErrByHTTPCode(httpStatusCode int, index int, message string) *errs.Err {
 	return errs.New(errCode, index, message)
 }
 
+// GetErrsMiddleware converts errors to responses and logs them with the client ip and the path,
+// they are taken from the context when GetLogContextMiddleware is installed before
 func GetErrsMiddleware(
 	serviceId int,
 	showUnknownErrorsInResponse bool,
@@ -111,14 +113,13 @@ func GetErrsMiddleware(
 
 		// logging
 
-		l.Error(
-			ctx,
-			fmt.Sprintf("%s | %d | %v", c.Path(), resp.Error.GetCode(), err),
-			zap.String("ip", c.IP()),
-			zap.String("path", c.Path()),
-			zap.Error(err),
-			log.Json("response", resp),
-		)
+		fields := []zap.Field(nil)
+		if c.Locals(logContextLocal) == nil {
+			fields = append(fields, zap.String("ip", c.IP()), zap.String("path", c.Path()))
+		}
+		fields = append(fields, zap.Error(err), log.Json("response", resp))
+
+		l.Error(ctx, fmt.Sprintf("%s | %d | %v", c.Path(), resp.Error.GetCode(), err), fields...)
 
 		return c.Status(resp.Error.GetCode()).JSON(resp)
 	}
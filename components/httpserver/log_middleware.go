@@ -0,0 +1,41 @@
+package httpserver
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+
+	"github.com/timmbarton/layout/log"
+)
+
+const RequestIdHeader = "X-Request-Id"
+
+// logContextLocal marks the requests which user context has the fields of GetLogContextMiddleware
+const logContextLocal = "layout.logContext"
+
+// GetLogContextMiddleware attaches the request id, method, path and client ip to the user context,
+// so that every entry logged with it includes them, see log.WithContext.
+// The request id is taken from the X-Request-Id header or generated if it is missing or invalid,
+// see log.IsValidRequestId, and returned in the response.
+func GetLogContextMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		requestId := strings.Clone(c.Get(RequestIdHeader))
+		if !log.IsValidRequestId(requestId) {
+			requestId = log.NewRequestId()
+		}
+		c.Set(RequestIdHeader, requestId)
+		c.Locals(logContextLocal, true)
+
+		// fiber reuses the strings of the request after the handler returns
+		ctx := log.WithContext(
+			log.WithRequestId(c.UserContext(), requestId),
+			zap.String("method", strings.Clone(c.Method())),
+			zap.String("path", strings.Clone(c.Path())),
+			zap.String("ip", strings.Clone(c.IP())),
+		)
+		c.SetUserContext(ctx)
+
+		return c.Next()
+	}
+}
@@ -10,6 +10,8 @@ import (
 	"go.opentelemetry.io/otel/sdk/resource"
 	tracesdk "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+
+	"github.com/timmbarton/layout/buildinfo"
 )
 
 type Config struct {
@@ -43,6 +45,7 @@ func (t *Tracer) Start(_ context.Context) error {
 		tracesdk.WithResource(resource.NewWithAttributes(
 			semconv.SchemaURL,
 			semconv.ServiceNameKey.String(t.cfg.ServiceName),
+			semconv.ServiceVersionKey.String(buildinfo.Get().Version),
 		)),
 	)
 	otel.SetTracerProvider(t.tp)
@@ -62,3 +65,4 @@ func (t *Tracer) Stop(ctx context.Context) error {
 	return errors.Join(err1, err2)
 }
 func (t *Tracer) GetName() string { return "Jaeger Tracing" }
+func (t *Tracer) GetAddr() string { return t.cfg.URL }
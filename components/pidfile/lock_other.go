@@ -0,0 +1,15 @@
+//go:build !unix
+
+package pidfile
+
+import (
+	"errors"
+	"os"
+)
+
+var errLocked = errors.New("file is locked")
+
+// lock is a no-op on platforms without flock, only the pid is written
+func lock(_ *os.File) error { return nil }
+
+func unlock(_ *os.File) error { return nil }
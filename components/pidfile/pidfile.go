@@ -0,0 +1,120 @@
+package pidfile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var ErrAlreadyRunning = errors.New("another instance is already running")
+
+// maxLockAttempts limits reopening of the file which is replaced while it is being locked
+const maxLockAttempts = 10
+
+type Config struct {
+	Path string `validate:"required"`
+}
+
+// PidFile is a lifecycle component which keeps an exclusive lock on the pid file
+// while the application is running. Add it first so that a second instance fails
+// before any other component is started.
+type PidFile struct {
+	cfg  Config
+	file *os.File
+}
+
+func New(cfg Config) *PidFile {
+	return &PidFile{
+		cfg: cfg,
+	}
+}
+
+func (p *PidFile) Start(_ context.Context) error {
+	file, err := p.openLocked()
+	if err != nil {
+		return err
+	}
+
+	err = file.Truncate(0)
+	if err == nil {
+		_, err = file.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	if err != nil {
+		return errors.Join(err, unlock(file), file.Close())
+	}
+
+	p.file = file
+
+	return nil
+}
+func (p *PidFile) Stop(_ context.Context) error {
+	if p.file == nil {
+		return nil
+	}
+
+	// remove the file while still holding the lock, so that a starting instance
+	// can't lock the file which is about to be removed
+	err := os.Remove(p.cfg.Path)
+	err = errors.Join(err, unlock(p.file), p.file.Close())
+	p.file = nil
+
+	return err
+}
+func (p *PidFile) GetName() string { return fmt.Sprintf("PID File at %s", p.cfg.Path) }
+
+// openLocked opens and locks the file at the path. The file may be removed by a stopping
+// instance between opening and locking, then the lock is taken again on the new file.
+func (p *PidFile) openLocked() (*os.File, error) {
+	for attempt := 1; ; attempt++ {
+		file, err := os.OpenFile(p.cfg.Path, os.O_RDWR|os.O_CREATE, 0o644)
+		if err != nil {
+			return nil, err
+		}
+
+		err = lock(file)
+		if errors.Is(err, errLocked) {
+			err = fmt.Errorf("%w: %s is locked by pid %s", ErrAlreadyRunning, p.cfg.Path, readPid(file))
+		}
+		if err != nil {
+			_ = file.Close()
+			return nil, err
+		}
+
+		locked, err := file.Stat()
+		if err == nil {
+			current, statErr := os.Stat(p.cfg.Path)
+			if statErr == nil && os.SameFile(locked, current) {
+				return file, nil
+			}
+			if statErr != nil && !errors.Is(statErr, os.ErrNotExist) {
+				err = statErr
+			}
+		}
+
+		err = errors.Join(err, unlock(file), file.Close())
+		if err != nil {
+			return nil, err
+		}
+		if attempt == maxLockAttempts {
+			return nil, fmt.Errorf("%s is replaced while being locked", p.cfg.Path)
+		}
+	}
+}
+
+func readPid(file *os.File) string {
+	buf := make([]byte, 32)
+
+	n, _ := file.ReadAt(buf, 0)
+	pid := strings.TrimSpace(string(buf[:n]))
+	if pid == "" {
+		return "unknown"
+	}
+
+	return pid
+}
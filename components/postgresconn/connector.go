@@ -6,19 +6,31 @@ import (
 
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
+
+	"github.com/timmbarton/layout/secret"
 )
 
 type Config struct {
-	Host           string      `validate:"required"`
-	Port           uint16      `validate:"required"`
-	Database       string      `validate:"required"`
-	User           string      `validate:"required"`
-	Password       string      `validate:"required"`
-	TLSConfig      *tls.Config // nil disables TLS
-	ConnectTimeout int         `validate:"required"` // seconds
+	Host           string        `validate:"required"`
+	Port           uint16        `validate:"required" default:"5432"`
+	Database       string        `validate:"required"`
+	User           string        `validate:"required"`
+	Password       secret.Secret `validate:"required"`
+	TLSConfig      *tls.Config   // nil disables TLS
+	ConnectTimeout int           `validate:"required" default:"10"` // seconds
 }
 
-func (c *Config) String() string {
+// String returns the connection string with the redacted password, see RedactedDSN.
+// Use DSN to connect.
+func (c *Config) String() string { return c.RedactedDSN() }
+
+// RedactedDSN returns the connection string with the redacted password for logs
+func (c *Config) RedactedDSN() string { return c.dsn(c.Password.String()) }
+
+// DSN returns the connection string for the driver
+func (c *Config) DSN() string { return c.dsn(c.Password.Value()) }
+
+func (c *Config) dsn(password string) string {
 	sslMode := "disable"
 	sslConfig := ""
 	if c.TLSConfig != nil {
@@ -31,7 +43,7 @@ func (c *Config) String() string {
 		c.Host,
 		c.Port,
 		c.User,
-		c.Password,
+		password,
 		c.Database,
 		sslMode,
 		sslConfig,
@@ -39,5 +51,5 @@ func (c *Config) String() string {
 }
 
 func Connect(cfg Config) (*sqlx.DB, error) {
-	return sqlx.Connect("postgres", cfg.String())
+	return sqlx.Connect("postgres", cfg.DSN())
 }
@@ -2,6 +2,7 @@ package postgresconn
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/jmoiron/sqlx"
 )
@@ -36,4 +37,5 @@ func (c *Conn) Stop(_ context.Context) error {
 	return nil
 }
 func (c *Conn) GetName() string { return "Postgres" }
+func (c *Conn) GetAddr() string { return fmt.Sprintf("%s:%d", c.cfg.Host, c.cfg.Port) }
 func (c *Conn) DB() *sqlx.DB    { return c.db }
@@ -4,13 +4,15 @@ import (
 	"fmt"
 
 	"github.com/go-redis/redis/v8"
+
+	"github.com/timmbarton/layout/secret"
 )
 
 type Config struct {
 	Host     string `validate:"required"`
-	Port     uint16 `validate:"required"`
+	Port     uint16 `validate:"required" default:"6379"`
 	Username string
-	Password string
+	Password secret.Secret
 	DB       int
 }
 
@@ -18,7 +20,7 @@ func Connect(cfg Config) (cl *redis.Client, err error) {
 	cl = redis.NewClient(&redis.Options{
 		Addr:     fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
 		Username: cfg.Username,
-		Password: cfg.Password,
+		Password: cfg.Password.Value(),
 		DB:       cfg.DB,
 	})
 
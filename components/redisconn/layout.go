@@ -2,6 +2,7 @@ package redisconn
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/go-redis/redis/v8"
 )
@@ -36,4 +37,5 @@ func (c *Conn) Stop(_ context.Context) error {
 	return nil
 }
 func (c *Conn) GetName() string       { return "Redis" }
+func (c *Conn) GetAddr() string       { return fmt.Sprintf("%s:%d", c.cfg.Host, c.cfg.Port) }
 func (c *Conn) Client() *redis.Client { return c.c }
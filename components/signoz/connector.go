@@ -17,7 +17,9 @@ import (
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
 	"go.uber.org/zap"
-	"go.uber.org/zap/zapcore"
+
+	"github.com/timmbarton/layout/buildinfo"
+	layoutlog "github.com/timmbarton/layout/log"
 )
 
 type Config struct {
@@ -49,20 +51,12 @@ func New(cfg Config, loggerOpts ...zap.Option) (c *Connector, err error) {
 		cfg: cfg,
 	}
 
-	loggerOpts = append(
-		[]zap.Option{
-			zap.AddCaller(),
-			zap.AddCallerSkip(1),
-			zap.AddStacktrace(zapcore.PanicLevel),
-		},
-		loggerOpts...,
-	)
-
 	// create signoz core
 	c.res, err = resource.New(
 		nil,
 		resource.WithAttributes(
 			semconv.ServiceNameKey.String(c.cfg.ServiceName),
+			semconv.ServiceVersionKey.String(buildinfo.Get().Version),
 			semconv.DeploymentEnvironmentKey.String(os.Getenv("ENV")),
 		),
 	)
@@ -100,25 +94,12 @@ func New(cfg Config, loggerOpts ...zap.Option) (c *Connector, err error) {
 		otelzap.WithLoggerProvider(c.log.provider),
 	)
 
-	// create console core
-	encoderCfg := zap.NewDevelopmentEncoderConfig()
-	encoderCfg.EncodeTime = zapcore.ISO8601TimeEncoder
-	encoder := zapcore.NewConsoleEncoder(encoderCfg)
-	ws := zapcore.AddSync(os.Stdout)
-	level := zap.NewAtomicLevelAt(zapcore.InfoLevel)
-
-	consoleCore := zapcore.NewCore(encoder, ws, level)
-
-	// create global logger
-
-	core := zapcore.NewTee(consoleCore, signozCore)
-
-	c.log.logger = zap.New(
-		core,
-		loggerOpts...,
-	)
-
-	zap.ReplaceGlobals(c.log.logger)
+	// add signoz core to the global logger replacing the core of a previous connector,
+	// the console output is set up by log.Setup
+	c.log.logger, err = layoutlog.AddCore("signoz", signozCore, loggerOpts...)
+	if err != nil {
+		return nil, err
+	}
 
 	return c, nil
 }
@@ -194,6 +175,8 @@ func (c *Connector) GetName() string {
 	return fmt.Sprintf("SigNoz Logging and Tracing (%s/%s)", os.Getenv("ENV"), c.cfg.ServiceName)
 }
 
+func (c *Connector) GetAddr() string { return c.cfg.URL }
+
 func (c *Connector) GetLogger() *zap.Logger {
 	return c.log.logger
 }
@@ -0,0 +1,279 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/timmbarton/layout/configloader"
+)
+
+const usage = `Usage: %[1]s <command> [flags] <args>
+
+Commands:
+  validate -type <name> [-env <env>] [-strict] <path>  validate the config file or directory
+  print -type <name> [-env <env>] [-format json|yaml] <path>  print the effective config with masked secrets
+  diff -type <name> <path> <env1> <env2>  compare the effective configs of two environments
+  schema -type <name>  print the JSON Schema of the config type
+  types  list the registered config types
+  keygen  generate a new encryption key
+  encrypt [-key-file <path>] [<value>]  encrypt the value or stdin
+  decrypt [-key-file <path>] <value>  decrypt the value
+  rotate [-key-file <path>] -new-key-file <path> <file>...  re-encrypt values of the config files with the new key
+
+Env variables, secret references and encrypted values in the config are resolved unless -offline is passed.
+Encryption keys are read from -key-file, $CONFIG_ENCRYPTION_KEY_FILE or $CONFIG_ENCRYPTION_KEY.
+`
+
+// Main runs the command with os.Args and exits with its code
+func Main() {
+	os.Exit(Run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// Run executes the command and returns the exit code, config types must be
+// registered with configloader.Register before
+func Run(args []string, stdout, stderr io.Writer) int {
+	name := "layout"
+	if len(os.Args) > 0 {
+		name = filepath.Base(os.Args[0])
+	}
+
+	if len(args) == 0 {
+		_, _ = fmt.Fprintf(stderr, usage, name)
+		return 2
+	}
+
+	c := command{stdout: stdout, stderr: stderr}
+
+	err := error(nil)
+	switch args[0] {
+	case "validate":
+		err = c.validate(args[1:])
+	case "print":
+		err = c.print(args[1:])
+	case "diff":
+		err = c.diff(args[1:])
+	case "schema":
+		err = c.schema(args[1:])
+	case "keygen":
+		err = c.keygen()
+	case "encrypt":
+		err = c.encrypt(args[1:])
+	case "decrypt":
+		err = c.decrypt(args[1:])
+	case "rotate":
+		err = c.rotate(args[1:])
+	case "types":
+		for _, t := range configloader.Registered() {
+			_, _ = fmt.Fprintln(stdout, t)
+		}
+	case "help", "-h", "--help":
+		_, _ = fmt.Fprintf(stdout, usage, name)
+	default:
+		_, _ = fmt.Fprintf(stderr, usage, name)
+		return 2
+	}
+
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.Is(err, errUsage):
+		_, _ = fmt.Fprintln(stderr, err)
+		return 2
+	default:
+		_, _ = fmt.Fprintln(stderr, err)
+		return 1
+	}
+}
+
+var errUsage = errors.New("invalid usage")
+
+type command struct {
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// flags are common for commands which load configs
+type flags struct {
+	typeName string
+	env      string
+	format   string
+	dotEnv   string
+	keyFile  string
+	strict   bool
+	offline  bool
+}
+
+func (c command) parse(name string, args []string, nArgs int) (*flags, []string, error) {
+	f := &flags{}
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.StringVar(&f.typeName, "type", "", "registered config type, see the types command")
+	fs.StringVar(&f.env, "env", os.Getenv("ENV"), "environment which overlay is loaded")
+	fs.StringVar(&f.format, "format", string(configloader.FormatJSON), "output format: json or yaml")
+	fs.StringVar(&f.dotEnv, "dotenv", "", ".env file with variables used by the config")
+	fs.StringVar(&f.keyFile, "key-file", "", "file with the keys of encrypted values")
+	fs.BoolVar(&f.strict, "strict", false, "reject unknown fields")
+	fs.BoolVar(&f.offline, "offline", false, "don't resolve env variables and secret references")
+
+	err := fs.Parse(args)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if f.typeName == "" {
+		return nil, nil, fmt.Errorf("%w: -type is required", errUsage)
+	}
+	if fs.NArg() != nArgs {
+		return nil, nil, fmt.Errorf("%w: %s expects %d arguments, got %d", errUsage, name, nArgs, fs.NArg())
+	}
+
+	return f, fs.Args(), nil
+}
+
+func (f *flags) newConfig() (any, error) {
+	cfg, ok := configloader.NewRegistered(f.typeName)
+	if !ok {
+		return nil, fmt.Errorf(
+			"%w: unknown config type %q, registered: %s",
+			errUsage,
+			f.typeName,
+			strings.Join(configloader.Registered(), ", "),
+		)
+	}
+
+	return cfg, nil
+}
+
+// load returns the loaded config and the paths of its decrypted values to be masked in the output
+func (f *flags) load(path string, env string) (any, []string, error) {
+	cfg, err := f.newConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	trace := configloader.Trace{}
+	opts := []configloader.Option{configloader.WithEnvironment(env), configloader.WithTrace(&trace)}
+	if f.dotEnv != "" {
+		opts = append(opts, configloader.WithDotEnv(f.dotEnv))
+	}
+	if f.keyFile != "" {
+		opts = append(opts, configloader.WithEncryptionKeyFile(f.keyFile))
+	}
+	if f.strict {
+		opts = append(opts, configloader.WithStrict())
+	}
+	if f.offline {
+		opts = append(opts, configloader.WithoutInterpolation(), configloader.WithoutSecretRefs(), configloader.WithoutDecryption())
+	}
+
+	err = configloader.LoadLayered(path, cfg, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cfg, trace.Decrypted(), nil
+}
+
+func (c command) validate(args []string) error {
+	f, rest, err := c.parse("validate", args, 1)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = f.load(rest[0], f.env)
+	if err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(c.stdout, "%s is a valid %s config\n", rest[0], f.typeName)
+
+	return nil
+}
+
+func (c command) print(args []string) error {
+	f, rest, err := c.parse("print", args, 1)
+	if err != nil {
+		return err
+	}
+
+	cfg, decrypted, err := f.load(rest[0], f.env)
+	if err != nil {
+		return err
+	}
+
+	data, err := configloader.Dump(cfg, configloader.Format(f.format), decrypted...)
+	if err != nil {
+		return err
+	}
+
+	_, _ = c.stdout.Write(data)
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		_, _ = fmt.Fprintln(c.stdout)
+	}
+
+	return nil
+}
+
+func (c command) diff(args []string) error {
+	f, rest, err := c.parse("diff", args, 3)
+	if err != nil {
+		return err
+	}
+
+	oldCfg, oldDecrypted, err := f.load(rest[0], rest[1])
+	if err != nil {
+		return fmt.Errorf("%s: %w", rest[1], err)
+	}
+
+	newCfg, newDecrypted, err := f.load(rest[0], rest[2])
+	if err != nil {
+		return fmt.Errorf("%s: %w", rest[2], err)
+	}
+
+	diffs := configloader.Diff(oldCfg, newCfg, append(oldDecrypted, newDecrypted...)...)
+	if len(diffs) == 0 {
+		_, _ = fmt.Fprintf(c.stdout, "no differences between %s and %s\n", rest[1], rest[2])
+		return nil
+	}
+
+	_, _ = fmt.Fprintf(c.stdout, "--- %s\n+++ %s\n", rest[1], rest[2])
+	for _, d := range diffs {
+		if d.Old != "" {
+			_, _ = fmt.Fprintf(c.stdout, "- %s: %s\n", d.Path, d.Old)
+		}
+		if d.New != "" {
+			_, _ = fmt.Fprintf(c.stdout, "+ %s: %s\n", d.Path, d.New)
+		}
+	}
+
+	return nil
+}
+
+func (c command) schema(args []string) error {
+	f, _, err := c.parse("schema", args, 0)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := f.newConfig()
+	if err != nil {
+		return err
+	}
+
+	data, err := configloader.Schema(cfg)
+	if err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintln(c.stdout, string(data))
+
+	return nil
+}
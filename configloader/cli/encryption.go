@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/timmbarton/layout/configloader"
+)
+
+func (c command) keygen() error {
+	key, err := configloader.GenerateKey()
+	if err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintln(c.stdout, key)
+
+	return nil
+}
+
+func (c command) encrypt(args []string) error {
+	fs, keyFile := c.keyFlags("encrypt")
+
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return fmt.Errorf("%w: encrypt expects at most 1 argument, got %d", errUsage, fs.NArg())
+	}
+
+	keys, err := loadKeys(*keyFile)
+	if err != nil {
+		return err
+	}
+
+	value := fs.Arg(0)
+	if fs.NArg() == 0 {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+
+		value = strings.TrimRight(string(data), "\r\n")
+	}
+
+	encrypted, err := configloader.Encrypt(keys[0], value)
+	if err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintln(c.stdout, encrypted)
+
+	return nil
+}
+
+func (c command) decrypt(args []string) error {
+	fs, keyFile := c.keyFlags("decrypt")
+
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("%w: decrypt expects 1 argument, got %d", errUsage, fs.NArg())
+	}
+
+	keys, err := loadKeys(*keyFile)
+	if err != nil {
+		return err
+	}
+
+	value, err := configloader.Decrypt(keys, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintln(c.stdout, value)
+
+	return nil
+}
+
+// rotate re-encrypts the values with the first new key, values already encrypted
+// with the new keys are re-encrypted as well, so that rotation may be repeated safely
+func (c command) rotate(args []string) error {
+	fs, keyFile := c.keyFlags("rotate")
+	newKeyFile := fs.String("new-key-file", "", "file with the new key")
+
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	if *newKeyFile == "" || fs.NArg() == 0 {
+		return fmt.Errorf("%w: rotate expects -new-key-file and config files", errUsage)
+	}
+
+	oldKeys, err := loadKeys(*keyFile)
+	if err != nil {
+		return err
+	}
+
+	newKeys, err := configloader.LoadKeys(*newKeyFile)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range fs.Args() {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		data, n, err := configloader.Reencrypt(data, append(newKeys, oldKeys...), newKeys[0])
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		err = os.WriteFile(path, data, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+
+		_, _ = fmt.Fprintf(c.stdout, "%s: %d values re-encrypted\n", path, n)
+	}
+
+	return nil
+}
+
+func (c command) keyFlags(name string) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	keyFile := fs.String("key-file", "", "file with the encryption keys, the first one is used for encryption")
+
+	return fs, keyFile
+}
+
+// loadKeys reads the keys from the file or from the default env variables
+func loadKeys(keyFile string) ([][]byte, error) {
+	if keyFile == "" {
+		keyFile = os.Getenv(configloader.EncryptionKeyFileEnv)
+	}
+	if keyFile != "" {
+		return configloader.LoadKeys(keyFile)
+	}
+
+	value, ok := os.LookupEnv(configloader.EncryptionKeyEnv)
+	if !ok {
+		return nil, fmt.Errorf(
+			"%w: use -key-file, %s or %s",
+			configloader.ErrNoEncryptionKey,
+			configloader.EncryptionKeyFileEnv,
+			configloader.EncryptionKeyEnv,
+		)
+	}
+
+	return configloader.ParseKeys(value)
+}
@@ -1,36 +1,212 @@
 package configloader
 
 import (
-	"encoding/json"
+	"errors"
+	"io"
 	"os"
+	"reflect"
+	"sync"
 
 	"github.com/timmbarton/utils/validation"
 )
 
+// Load loads config from the --config flag when flags are enabled, then from CONFIG_FILE_PATH
+// and ./.config/config.json by default, together with its environment and local overlays, see LoadLayered
+//
 //goland:noinspection ALL
-func Load(dest any) error {
+func Load(dest any, opts ...Option) error {
+	o := newOptions(opts)
+
+	err := applyDefaults(dest, o.getTrace())
+	if err != nil {
+		return err
+	}
+
+	err = o.parseFlags(dest)
+	if err != nil {
+		return err
+	}
+
 	configFilePath := os.Getenv("CONFIG_FILE_PATH")
+	if o.flags != nil && o.flags.configPath != "" {
+		configFilePath = o.flags.configPath
+	}
 	if configFilePath == "" {
 		configFilePath = "./.config/config.json"
 	}
-	return LoadFromJsonFile(configFilePath, dest)
+	return loadLayered(configFilePath, dest, o)
+}
+
+// LoadLayered loads the base config file and deep merges the optional overlays on top of it:
+// config.<ENV>.json and then config.local.json placed next to config.json.
+// If basePath is a directory, all its config files are merged in lexical order instead.
+func LoadLayered(basePath string, dest any, opts ...Option) error {
+	return loadLayered(basePath, dest, newOptions(opts))
+}
+
+func loadLayered(basePath string, dest any, o *options) error {
+	layers, err := loadLayers(basePath, o.environment)
+	if err != nil {
+		return err
+	}
+
+	return load(layers, dest, o)
 }
 
-func LoadFromJsonFile(filePath string, dest any) error {
+// LoadDir merges all config files of the directory in lexical order,
+// e.g. 00-base.json, 10-db.yaml, 99-local.json
+func LoadDir(dirPath string, dest any, opts ...Option) error {
+	o := newOptions(opts)
+
+	paths, err := fragmentPaths(dirPath)
+	if err != nil {
+		return err
+	}
+
+	layers, err := loadFileLayers(paths)
+	if err != nil {
+		return err
+	}
+
+	return load(layers, dest, o)
+}
+
+// LoadFromFile loads config from the file, the format is picked by the file extension
+func LoadFromFile(filePath string, dest any, opts ...Option) error {
+	l, err := loadFileLayer(filePath)
+	if err != nil {
+		return err
+	}
+
+	return load([]layer{l}, dest, newOptions(opts))
+}
+
+func LoadFromJsonFile(filePath string, dest any, opts ...Option) error {
 	file, err := os.Open(filePath)
 	if err != nil {
 		return err
 	}
+	defer file.Close()
 
-	err = json.NewDecoder(file).Decode(dest)
+	l, err := decodeLayer(file, FormatJSON, fileSourcePrefix+filePath)
 	if err != nil {
 		return err
 	}
 
-	err = validation.ValidateStruct(dest)
+	return load([]layer{l}, dest, newOptions(opts))
+}
+
+func LoadFromReader(r io.Reader, format Format, dest any, opts ...Option) error {
+	l, err := decodeLayer(r, format, "reader")
 	if err != nil {
 		return err
 	}
 
+	return load([]layer{l}, dest, newOptions(opts))
+}
+
+func load(layers []layer, dest any, o *options) error {
+	trace := o.getTrace()
+
+	// defaults go first, so that they are shown in the flags usage
+	err := applyDefaults(dest, trace)
+	if err != nil {
+		return err
+	}
+
+	err = o.parseFlags(dest)
+	if err != nil {
+		return err
+	}
+
+	lookup, err := o.envLookup()
+	if err != nil {
+		return err
+	}
+
+	if !o.noInterp {
+		err = interpolateLayers(layers, lookup)
+		if err != nil {
+			return err
+		}
+	}
+
+	order := make([]string, len(layers))
+	for i, l := range layers {
+		order[i] = l.name
+	}
+	if o.order != nil {
+		*o.order = order
+	}
+
+	merged, err := mergeLayers(layers, trace)
+	if err != nil {
+		return err
+	}
+
+	loc := locator{layers: layers, trace: trace}
+
+	if !o.noDecrypt {
+		errs := []error(nil)
+		getKeys := sync.OnceValues(func() ([][]byte, error) { return o.getEncryptionKeys(lookup) })
+
+		merged = decryptTree(merged, "", getKeys, trace, func(path string, err error) {
+			errs = append(errs, loc.fieldError(path, err))
+		})
+		if len(errs) > 0 {
+			return errors.Join(errs...)
+		}
+	}
+
+	tree := coerceTree(merged, reflect.TypeOf(dest))
+
+	if o.strict {
+		unknown := unknownFields(tree, reflect.TypeOf(dest), "")
+		if len(unknown) > 0 {
+			errs := make([]error, 0, len(unknown))
+			for _, path := range unknown {
+				errs = append(errs, loc.fieldError(path, ErrUnknownField))
+			}
+
+			return errors.Join(errs...)
+		}
+	}
+
+	err = applySectionDefaults(dest, tree, trace)
+	if err != nil {
+		return err
+	}
+
+	err = decodeInto(tree, dest)
+	if err != nil {
+		return loc.decodeError(err)
+	}
+
+	err = applyEnv(dest, o.envPrefix, lookup, trace)
+	if err != nil {
+		return err
+	}
+
+	err = applyFlags(dest, o.flags, trace)
+	if err != nil {
+		return err
+	}
+
+	if !o.noRefs {
+		err = resolveRefs(dest, lookup)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = validation.ValidateStruct(dest)
+	if err != nil {
+		return loc.validationError(dest, err)
+	}
+
+	if o.logDump {
+		logDump(dest, order, trace)
+	}
+
 	return nil
 }
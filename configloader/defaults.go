@@ -0,0 +1,154 @@
+package configloader
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const defaultTag = "default"
+
+// applyDefaults sets values from the `default` tag to zero fields of dest,
+// nested structs are processed recursively while nil pointers to structs are kept nil
+func applyDefaults(dest any, trace Trace) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return nil
+	}
+
+	return applyDefaultsToValue(v.Elem(), "", trace)
+}
+
+func applyDefaultsToValue(v reflect.Value, path string, trace Trace) error {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		fv := v.Field(i)
+		fieldPath := joinPath(path, fieldName(field))
+
+		def, hasDefault := field.Tag.Lookup(defaultTag)
+		if !hasDefault {
+			if isNested(field.Type) {
+				nestedPath := fieldPath
+				if field.Anonymous {
+					nestedPath = path
+				}
+
+				err := applyDefaultsToValue(fv, nestedPath, trace)
+				if err != nil {
+					return err
+				}
+			}
+
+			continue
+		}
+
+		if !fv.IsZero() {
+			continue
+		}
+
+		err := setFromString(fv, def)
+		if err != nil {
+			return fmt.Errorf("default of %s: %w", fieldPath, err)
+		}
+
+		trace.replace(fieldPath, "default")
+	}
+
+	return nil
+}
+
+// applySectionDefaults allocates nil pointers to structs which are present in the tree
+// and applies defaults to them, so that optional sections get defaults once they are configured
+func applySectionDefaults(dest any, tree any, trace Trace) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return nil
+	}
+
+	return applySectionDefaultsToValue(v.Elem(), tree, "", trace)
+}
+
+func applySectionDefaultsToValue(v reflect.Value, tree any, path string, trace Trace) error {
+	m, ok := tree.(map[string]any)
+	if !ok || v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() || !isNested(field.Type) {
+			continue
+		}
+
+		fv := v.Field(i)
+		fieldPath, subtree := joinPath(path, fieldName(field)), treeKey(m, fieldName(field))
+		if field.Anonymous {
+			fieldPath, subtree = path, tree
+		}
+
+		if fv.Kind() == reflect.Pointer {
+			if _, isMap := subtree.(map[string]any); !isMap {
+				continue
+			}
+
+			if fv.IsNil() {
+				fv.Set(reflect.New(field.Type.Elem()))
+
+				// values from the tree are traced already and override defaults while decoding
+				defaults := Trace{}
+
+				err := applyDefaultsToValue(fv, fieldPath, defaults)
+				if err != nil {
+					return err
+				}
+
+				for p, source := range defaults {
+					if _, traced := trace[p]; !traced && trace != nil {
+						trace[p] = source
+					}
+				}
+			}
+
+			fv = fv.Elem()
+		}
+
+		err := applySectionDefaultsToValue(fv, subtree, fieldPath, trace)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// treeKey returns the value of the key matched case-insensitively like json does
+func treeKey(m map[string]any, key string) any {
+	if v, ok := m[key]; ok {
+		return v
+	}
+
+	for k, v := range m {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+
+	return nil
+}
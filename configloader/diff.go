@@ -0,0 +1,64 @@
+package configloader
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+// FieldDiff is a difference of the field between two configs, values are rendered as in Dump
+// with secrets masked, so changes of masked values are not visible
+type FieldDiff struct {
+	Path string
+	Old  string // empty if the field is missing in the old config
+	New  string // empty if the field is missing in the new config
+}
+
+// Diff compares the effective configs field by field, values of maskPaths are masked
+// in both configs, e.g. the decrypted paths of any of them, see Trace.Decrypted
+func Diff(oldCfg, newCfg any, maskPaths ...string) []FieldDiff {
+	oldValues, newValues := map[string]string{}, map[string]string{}
+	flattenTree(maskTree(dumpTree(reflect.ValueOf(oldCfg), false), maskPaths), "", oldValues)
+	flattenTree(maskTree(dumpTree(reflect.ValueOf(newCfg), false), maskPaths), "", newValues)
+
+	paths := make([]string, 0, len(oldValues)+len(newValues))
+	for p := range oldValues {
+		paths = append(paths, p)
+	}
+	for p := range newValues {
+		if _, ok := oldValues[p]; !ok {
+			paths = append(paths, p)
+		}
+	}
+	slices.Sort(paths)
+
+	diffs := []FieldDiff(nil)
+	for _, p := range paths {
+		if oldValues[p] != newValues[p] {
+			diffs = append(diffs, FieldDiff{Path: p, Old: oldValues[p], New: newValues[p]})
+		}
+	}
+
+	return diffs
+}
+
+func flattenTree(tree any, path string, values map[string]string) {
+	switch tree := tree.(type) {
+	case map[string]any:
+		for k, v := range tree {
+			flattenTree(v, joinPath(path, k), values)
+		}
+	case []any:
+		for i, v := range tree {
+			flattenTree(v, indexPath(path, i), values)
+		}
+	default:
+		data, err := json.Marshal(tree)
+		if err != nil {
+			data = []byte(fmt.Sprint(tree))
+		}
+
+		values[path] = string(data)
+	}
+}
@@ -0,0 +1,287 @@
+package configloader
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+const DefaultDotEnvPath = ".env"
+
+var ErrDotEnvSyntax = errors.New("invalid .env syntax")
+
+// envLookup returns the value of the environment variable
+type envLookup func(name string) (string, bool)
+
+// LoadDotEnv sets the variables from the .env files into the process environment,
+// variables which are already set are not overridden. Missing files are skipped,
+// no paths means DefaultDotEnvPath.
+func LoadDotEnv(paths ...string) error {
+	values, err := readDotEnvFiles(paths)
+	if err != nil {
+		return err
+	}
+
+	for name, value := range values {
+		if _, ok := os.LookupEnv(name); ok {
+			continue
+		}
+
+		err = os.Setenv(name, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WithDotEnv uses the variables from the .env files for env overrides, interpolation
+// and env:// references without changing the process environment. The real environment
+// takes precedence. Missing files are skipped, no paths means DefaultDotEnvPath.
+func WithDotEnv(paths ...string) Option {
+	return func(o *options) {
+		if len(paths) == 0 {
+			paths = []string{DefaultDotEnvPath}
+		}
+
+		o.dotEnvPaths = append(o.dotEnvPaths, paths...)
+	}
+}
+
+// envLookup returns the lookup of the process environment with the .env files behind it
+func (o *options) envLookup() (envLookup, error) {
+	if len(o.dotEnvPaths) == 0 {
+		return os.LookupEnv, nil
+	}
+
+	values, err := readDotEnvFiles(o.dotEnvPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(name string) (string, bool) {
+		if value, ok := os.LookupEnv(name); ok {
+			return value, true
+		}
+
+		value, ok := values[name]
+
+		return value, ok
+	}, nil
+}
+
+// readDotEnvFiles reads the files in order, the later ones override the earlier ones
+func readDotEnvFiles(paths []string) (map[string]string, error) {
+	if len(paths) == 0 {
+		paths = []string{DefaultDotEnvPath}
+	}
+
+	values := map[string]string{}
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		err = parseDotEnv(string(data), p, values)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return values, nil
+}
+
+// parseDotEnv parses lines like KEY=value into values. It supports comments, `export` prefixes,
+// single-quoted literal values and double-quoted values with escapes, both may span several lines.
+func parseDotEnv(data string, name string, values map[string]string) error {
+	p := dotEnvParser{data: strings.ReplaceAll(data, "\r\n", "\n"), name: name, line: 1}
+
+	for {
+		p.skipSpace()
+		if p.eof() {
+			return nil
+		}
+
+		switch p.peek() {
+		case '\n':
+			p.next()
+			continue
+		case '#':
+			p.skipLine()
+			continue
+		}
+
+		key, err := p.key()
+		if err != nil {
+			return err
+		}
+
+		value, err := p.value(key)
+		if err != nil {
+			return err
+		}
+
+		values[key] = value
+	}
+}
+
+type dotEnvParser struct {
+	data   string
+	name   string
+	pos    int
+	line   int
+	column int
+}
+
+func (p *dotEnvParser) eof() bool  { return p.pos >= len(p.data) }
+func (p *dotEnvParser) peek() byte { return p.data[p.pos] }
+
+func (p *dotEnvParser) next() byte {
+	c := p.data[p.pos]
+	p.pos++
+	p.column++
+	if c == '\n' {
+		p.line++
+		p.column = 0
+	}
+
+	return c
+}
+
+// skipSpace skips spaces and tabs but not line breaks
+func (p *dotEnvParser) skipSpace() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.next()
+	}
+}
+
+func (p *dotEnvParser) skipLine() {
+	for !p.eof() && p.next() != '\n' {
+	}
+}
+
+func (p *dotEnvParser) errorf(key string, format string, args ...any) error {
+	return &FieldError{
+		Source: p.name,
+		Line:   p.line,
+		Column: p.column + 1,
+		Path:   key,
+		Err:    fmt.Errorf("%w: "+format, append([]any{ErrDotEnvSyntax}, args...)...),
+	}
+}
+
+func (p *dotEnvParser) key() (string, error) {
+	column := p.column
+	key := p.word()
+	if key == "export" && !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.skipSpace()
+		column = p.column
+		key = p.word()
+	}
+
+	if !isVariableName(key) {
+		p.column = column
+		return "", p.errorf("", "invalid variable name %q", key)
+	}
+
+	p.skipSpace()
+	if p.eof() || p.peek() != '=' {
+		return "", p.errorf(key, "expected '=' after the variable name")
+	}
+	p.next()
+	p.skipSpace()
+
+	return key, nil
+}
+
+func (p *dotEnvParser) word() string {
+	start := p.pos
+	for !p.eof() && !strings.ContainsRune(" \t\n=#", rune(p.peek())) {
+		p.next()
+	}
+
+	return p.data[start:p.pos]
+}
+
+func (p *dotEnvParser) value(key string) (string, error) {
+	if p.eof() {
+		return "", nil
+	}
+
+	switch p.peek() {
+	case '\'':
+		return p.quoted(key, '\'')
+	case '"':
+		return p.quoted(key, '"')
+	}
+
+	start := p.pos
+	end := p.pos
+	for !p.eof() && p.peek() != '\n' {
+		// the comment starts with # after a space
+		if p.peek() == '#' && p.pos > start && (p.data[p.pos-1] == ' ' || p.data[p.pos-1] == '\t') {
+			p.skipLine()
+			break
+		}
+
+		p.next()
+		end = p.pos
+	}
+
+	return strings.TrimRight(p.data[start:end], " \t"), nil
+}
+
+// quoted reads the value until the closing quote, escapes are processed in double quotes only
+func (p *dotEnvParser) quoted(key string, quote byte) (string, error) {
+	line, column := p.line, p.column
+	p.next()
+
+	b := strings.Builder{}
+	for {
+		if p.eof() {
+			p.line, p.column = line, column
+			return "", p.errorf(key, "unterminated quoted value")
+		}
+
+		c := p.next()
+		if c == quote {
+			break
+		}
+
+		if c == '\\' && quote == '"' && !p.eof() {
+			switch e := p.next(); e {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '"', '\\', '$':
+				b.WriteByte(e)
+			default:
+				b.WriteByte('\\')
+				b.WriteByte(e)
+			}
+
+			continue
+		}
+
+		b.WriteByte(c)
+	}
+
+	// only a comment may follow the closing quote
+	p.skipSpace()
+	if !p.eof() && p.peek() != '\n' && p.peek() != '#' {
+		return "", p.errorf(key, "unexpected %q after the quoted value", p.peek())
+	}
+	p.skipLine()
+
+	return b.String(), nil
+}
@@ -0,0 +1,96 @@
+package configloader
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseDotEnv(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want map[string]string
+	}{
+		{name: "plain", data: "A=1\nB = two words \n", want: map[string]string{"A": "1", "B": "two words"}},
+		{name: "empty", data: "A=\nB=\"\"\n", want: map[string]string{"A": "", "B": ""}},
+		{name: "export", data: "export A=1\nexport=2\n", want: map[string]string{"A": "1", "export": "2"}},
+		{name: "comments", data: "# comment\n  # indented\nA=1 # comment\nB=a#b\n", want: map[string]string{"A": "1", "B": "a#b"}},
+		{name: "crlf", data: "A=1\r\nB='2'\r\n", want: map[string]string{"A": "1", "B": "2"}},
+		{name: "single quotes", data: `A='a \n $B # c'`, want: map[string]string{"A": `a \n $B # c`}},
+		{name: "double quotes", data: `A="a\n\t\"b\" \\ \$c \x" # comment`, want: map[string]string{"A": "a\n\t\"b\" \\ $c \\x"}},
+		{name: "multi-line", data: "A=\"first\nsecond\"\nB='x\ny'\nC=3\n", want: map[string]string{"A": "first\nsecond", "B": "x\ny", "C": "3"}},
+		{name: "later wins", data: "A=1\nA=2\n", want: map[string]string{"A": "2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := map[string]string{}
+
+			err := parseDotEnv(tt.data, ".env", got)
+			if err != nil {
+				t.Fatalf("parseDotEnv() error = %v", err)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("parseDotEnv() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDotEnvErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		location string
+	}{
+		{name: "invalid name", data: "A=1\n1A=2\n", location: ".env:2:1"},
+		{name: "no equal sign", data: "A\n", location: ".env:1:2"},
+		{name: "unterminated quote", data: "A=1\nB=\"value\n", location: ".env:2:3"},
+		{name: "text after quote", data: "A='a' b\n", location: ".env:1:7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := parseDotEnv(tt.data, ".env", map[string]string{})
+			if !errors.Is(err, ErrDotEnvSyntax) {
+				t.Fatalf("parseDotEnv() error = %v, want %v", err, ErrDotEnvSyntax)
+			}
+			if !strings.Contains(err.Error(), tt.location) {
+				t.Errorf("parseDotEnv() error = %v, want the location %s", err, tt.location)
+			}
+		})
+	}
+}
+
+func TestWithDotEnv(t *testing.T) {
+	dir := t.TempDir()
+	base, local := filepath.Join(dir, ".env"), filepath.Join(dir, ".env.local")
+
+	writeFile(t, base, "ZZ_HOST=base\nZZ_PORT=5432\nZZ_USER=base\n")
+	writeFile(t, local, "ZZ_HOST=local\n")
+	t.Setenv("ZZ_USER", "process")
+
+	cfg := struct {
+		Host string
+		Port int
+		User string
+	}{}
+
+	err := LoadFromReader(strings.NewReader(`{}`), FormatJSON, &cfg,
+		WithEnvPrefix("ZZ"), WithDotEnv(base, local, filepath.Join(dir, "missing")))
+	if err != nil {
+		t.Fatalf("LoadFromReader() error = %v", err)
+	}
+
+	// later files override earlier ones, the process environment overrides the files
+	if cfg.Host != "local" || cfg.Port != 5432 || cfg.User != "process" {
+		t.Errorf("config = %+v, want local, 5432, process", cfg)
+	}
+	if _, ok := os.LookupEnv("ZZ_HOST"); ok {
+		t.Error("WithDotEnv changed the process environment")
+	}
+}
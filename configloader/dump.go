@@ -0,0 +1,253 @@
+package configloader
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+
+	"github.com/timmbarton/layout/log"
+	"github.com/timmbarton/layout/secret"
+)
+
+var (
+	secretType        = reflect.TypeOf(Secret(""))
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// Dump renders the effective config as json or yaml with secrets and password-like fields masked.
+// Fields which can't be configured from files, like funcs and channels, are skipped.
+// Values of maskPaths are masked as well, e.g. the decrypted ones, see Trace.Decrypted.
+func Dump(cfg any, format Format, maskPaths ...string) ([]byte, error) {
+	tree := maskTree(dumpTree(reflect.ValueOf(cfg), false), maskPaths)
+
+	switch format {
+	case FormatJSON:
+		return json.MarshalIndent(tree, "", "  ")
+	case FormatYAML:
+		return yaml.Marshal(tree)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+}
+
+// DumpHandler serves the effective config as json, or as yaml with ?format=yaml, see Dump for maskPaths
+func DumpHandler(get func() any, maskPaths ...string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		format, contentType := FormatJSON, "application/json"
+		if Format(r.URL.Query().Get("format")) == FormatYAML {
+			format, contentType = FormatYAML, "application/yaml"
+		}
+
+		data, err := Dump(get(), format, maskPaths...)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		_, _ = w.Write(data)
+	})
+}
+
+// logDump writes the effective config and the sources of its values to the debug log
+func logDump(dest any, order []string, trace Trace) {
+	data, err := Dump(dest, FormatJSON, trace.Decrypted()...)
+	if err != nil {
+		log.Named("Config").Warn(context.Background(), "can't dump config", zap.Error(err))
+		return
+	}
+
+	log.Named("Config").Debug(
+		context.Background(),
+		"effective config",
+		zap.String("config", string(data)),
+		zap.Strings("merge_order", order),
+		zap.String("sources", trace.String()),
+	)
+}
+
+func dumpTree(v reflect.Value, mask bool) any {
+	if !v.IsValid() {
+		return nil
+	}
+
+	// masked values are hidden entirely, including nested structs like private keys
+	if mask || v.Type() == secretType {
+		if v.IsZero() {
+			return dumpZero(v)
+		}
+
+		return secret.Redacted
+	}
+
+	t := v.Type()
+	switch {
+	case t == secondsType:
+		return int64(time.Duration(v.Int()) / time.Second)
+	case t == durationType:
+		return time.Duration(v.Int()).String()
+	case t.Implements(jsonMarshalerType) && (v.Kind() != reflect.Pointer || !v.IsNil()):
+		data, err := v.Interface().(json.Marshaler).MarshalJSON()
+		if err != nil {
+			return nil
+		}
+
+		tree := any(nil)
+		if json.Unmarshal(data, &tree) != nil {
+			return nil
+		}
+
+		return tree
+	case t.Implements(textMarshalerType) && (v.Kind() != reflect.Pointer || !v.IsNil()):
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return nil
+		}
+
+		return string(text)
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+
+		return dumpTree(v.Elem(), false)
+	case reflect.Struct:
+		m := map[string]any{}
+		dumpStruct(v, m)
+
+		return m
+	case reflect.Map:
+		m := make(map[string]any, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			key := fmt.Sprint(iter.Key().Interface())
+			m[key] = dumpTree(iter.Value(), isMaskedName(key))
+		}
+
+		return m
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+
+		s := make([]any, v.Len())
+		for i := range s {
+			s[i] = dumpTree(v.Index(i), false)
+		}
+
+		return s
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer, reflect.Complex64, reflect.Complex128:
+		return nil
+	default:
+		return v.Interface()
+	}
+}
+
+// maskTree masks the values of the dumped tree by their lower-case dotted paths
+func maskTree(tree any, maskPaths []string) any {
+	if len(maskPaths) == 0 {
+		return tree
+	}
+
+	masked := make(map[string]bool, len(maskPaths))
+	for _, p := range maskPaths {
+		masked[strings.ToLower(p)] = true
+	}
+
+	return maskTreePaths(tree, "", masked)
+}
+
+func maskTreePaths(tree any, path string, masked map[string]bool) any {
+	if masked[path] {
+		if tree == nil || tree == "" {
+			return tree
+		}
+
+		return secret.Redacted
+	}
+
+	switch tree := tree.(type) {
+	case map[string]any:
+		for k, v := range tree {
+			tree[k] = maskTreePaths(v, joinPath(path, k), masked)
+		}
+	case []any:
+		for i, v := range tree {
+			tree[i] = maskTreePaths(v, indexPath(path, i), masked)
+		}
+	}
+
+	return tree
+}
+
+func dumpZero(v reflect.Value) any {
+	if v.Kind() == reflect.String {
+		return ""
+	}
+
+	return nil
+}
+
+func dumpStruct(v reflect.Value, m map[string]any) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if strings.HasPrefix(field.Tag.Get("json"), "-") || !isDumpable(field.Type) {
+			continue
+		}
+
+		fv := v.Field(i)
+		jsonName, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+
+		if field.Anonymous && jsonName == "" {
+			for fv.Kind() == reflect.Pointer && !fv.IsNil() {
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				dumpStruct(fv, m)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		name := fieldName(field)
+		m[name] = dumpTree(fv, isMaskedName(name))
+	}
+}
+
+func isDumpable(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return false
+	default:
+		return true
+	}
+}
+
+// isMaskedName reports whether values of the field are masked in Dump:
+// names of secret.SensitiveNames and names ending with "key" or "keys"
+func isMaskedName(name string) bool {
+	name = strings.ToLower(name)
+	if strings.HasSuffix(name, "key") || strings.HasSuffix(name, "keys") {
+		return true
+	}
+
+	return secret.IsSensitiveName(name)
+}
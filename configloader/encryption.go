@@ -0,0 +1,262 @@
+package configloader
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const (
+	// EncryptedPrefix marks encrypted config values: enc:AES256-GCM:<base64 of nonce and ciphertext>
+	EncryptedPrefix = "enc:AES256-GCM:"
+
+	// EncryptionKeyEnv and EncryptionKeyFileEnv are the default sources of the decryption keys
+	EncryptionKeyEnv     = "CONFIG_ENCRYPTION_KEY"
+	EncryptionKeyFileEnv = "CONFIG_ENCRYPTION_KEY_FILE"
+
+	encryptionKeySize = 32
+)
+
+var (
+	ErrNoEncryptionKey  = errors.New("encryption key is not set")
+	ErrInvalidKey       = errors.New("invalid encryption key")
+	ErrDecryptionFailed = errors.New("can't decrypt value")
+
+	// encryptedRe matches encrypted values inside config documents
+	encryptedRe = regexp.MustCompile(regexp.QuoteMeta(EncryptedPrefix) + `[A-Za-z0-9+/=]+`)
+)
+
+// WithEncryptionKeys sets the keys for decryption of encrypted values,
+// several keys allow to decrypt values encrypted before the key rotation
+func WithEncryptionKeys(keys ...[]byte) Option {
+	return func(o *options) { o.encryptionKeys = append(o.encryptionKeys, keys...) }
+}
+
+// WithEncryptionKeyFile reads the decryption keys from the file, see ParseKeys
+func WithEncryptionKeyFile(path string) Option {
+	return func(o *options) { o.encryptionKeyFile = path }
+}
+
+// WithoutDecryption keeps encrypted values as they are
+func WithoutDecryption() Option {
+	return func(o *options) { o.noDecrypt = true }
+}
+
+// getEncryptionKeys returns the keys from the options, or from the file
+// or the value of the default env variables
+func (o *options) getEncryptionKeys(lookup envLookup) ([][]byte, error) {
+	if len(o.encryptionKeys) > 0 {
+		return o.encryptionKeys, nil
+	}
+
+	keyFile := o.encryptionKeyFile
+	if keyFile == "" {
+		keyFile, _ = lookup(EncryptionKeyFileEnv)
+	}
+	if keyFile != "" {
+		return LoadKeys(keyFile)
+	}
+
+	if value, ok := lookup(EncryptionKeyEnv); ok {
+		return ParseKeys(value)
+	}
+
+	return nil, ErrNoEncryptionKey
+}
+
+// GenerateKey returns a new random key encoded as base64
+func GenerateKey() (string, error) {
+	key := make([]byte, encryptionKeySize)
+
+	_, err := rand.Read(key)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// LoadKeys reads the keys from the file, see ParseKeys
+func LoadKeys(path string) ([][]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseKeys(string(data))
+}
+
+// ParseKeys parses base64 encoded 32 bytes keys separated by commas or new lines,
+// the first key is the current one
+func ParseKeys(s string) ([][]byte, error) {
+	keys := [][]byte(nil)
+
+	for _, field := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '\n' || r == '\r' }) {
+		field = strings.TrimSpace(field)
+		if field == "" || strings.HasPrefix(field, "#") {
+			continue
+		}
+
+		key, err := base64.StdEncoding.DecodeString(field)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidKey, err)
+		}
+		if len(key) != encryptionKeySize {
+			return nil, fmt.Errorf("%w: %d bytes instead of %d", ErrInvalidKey, len(key), encryptionKeySize)
+		}
+
+		keys = append(keys, key)
+	}
+
+	if len(keys) == 0 {
+		return nil, ErrNoEncryptionKey
+	}
+
+	return keys, nil
+}
+
+// IsEncrypted reports whether the value is encrypted by Encrypt
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, EncryptedPrefix)
+}
+
+// Encrypt encrypts the value with AES-256-GCM and returns it with EncryptedPrefix
+func Encrypt(key []byte, value string) (string, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(value)+aead.Overhead())
+
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(value), []byte(EncryptedPrefix))
+
+	return EncryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts the value encrypted by Encrypt with any of the keys
+func Decrypt(keys [][]byte, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return "", fmt.Errorf("%w: no %s prefix", ErrDecryptionFailed, EncryptedPrefix)
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, EncryptedPrefix))
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrDecryptionFailed, err)
+	}
+
+	for _, key := range keys {
+		aead, err := newAEAD(key)
+		if err != nil {
+			return "", err
+		}
+
+		if len(sealed) < aead.NonceSize() {
+			return "", fmt.Errorf("%w: value is too short", ErrDecryptionFailed)
+		}
+
+		plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(EncryptedPrefix))
+		if err == nil {
+			return string(plain), nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: no matching key", ErrDecryptionFailed)
+}
+
+// Reencrypt replaces all encrypted values in the config document with values encrypted by the new key,
+// it keeps the rest of the document intact and returns the number of replaced values
+func Reencrypt(data []byte, oldKeys [][]byte, newKey []byte) ([]byte, int, error) {
+	errs, n := []error(nil), 0
+
+	result := encryptedRe.ReplaceAllFunc(data, func(match []byte) []byte {
+		plain, err := Decrypt(oldKeys, string(match))
+		if err != nil {
+			errs = append(errs, err)
+			return match
+		}
+
+		encrypted, err := Encrypt(newKey, plain)
+		if err != nil {
+			errs = append(errs, err)
+			return match
+		}
+
+		n++
+
+		return []byte(encrypted)
+	})
+
+	return result, n, errors.Join(errs...)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != encryptionKeySize {
+		return nil, fmt.Errorf("%w: %d bytes instead of %d", ErrInvalidKey, len(key), encryptionKeySize)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// decryptTree replaces encrypted string values of the merged tree and marks their paths in the trace,
+// the keys are requested only if there are encrypted values
+func decryptTree(
+	tree any,
+	path string,
+	getKeys func() ([][]byte, error),
+	trace Trace,
+	onErr func(path string, err error),
+) any {
+	switch tree := tree.(type) {
+	case map[string]any:
+		for k, v := range tree {
+			tree[k] = decryptTree(v, joinPath(path, k), getKeys, trace, onErr)
+		}
+
+		return tree
+	case []any:
+		for i, v := range tree {
+			tree[i] = decryptTree(v, indexPath(path, i), getKeys, trace, onErr)
+		}
+
+		return tree
+	case string:
+		if !IsEncrypted(tree) {
+			return tree
+		}
+
+		keys, err := getKeys()
+		if err != nil {
+			onErr(path, err)
+			return tree
+		}
+
+		plain, err := Decrypt(keys, tree)
+		if err != nil {
+			onErr(path, err)
+			return tree
+		}
+
+		trace.markDecrypted(path)
+
+		return plain
+	default:
+		return tree
+	}
+}
@@ -0,0 +1,218 @@
+package configloader
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func newTestKey(t *testing.T) []byte {
+	t.Helper()
+
+	encoded, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+
+	keys, err := ParseKeys(encoded)
+	if err != nil {
+		t.Fatalf("ParseKeys() error = %v", err)
+	}
+
+	return keys[0]
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	key := newTestKey(t)
+
+	for _, value := range []string{"", "password", "пароль with spaces\nand lines"} {
+		encrypted, err := Encrypt(key, value)
+		if err != nil {
+			t.Fatalf("Encrypt(%q) error = %v", value, err)
+		}
+		if !IsEncrypted(encrypted) {
+			t.Errorf("Encrypt(%q) = %q, want %s prefix", value, encrypted, EncryptedPrefix)
+		}
+
+		again, _ := Encrypt(key, value)
+		if again == encrypted {
+			t.Errorf("Encrypt(%q) returns the same value twice", value)
+		}
+
+		decrypted, err := Decrypt([][]byte{key}, encrypted)
+		if err != nil {
+			t.Fatalf("Decrypt() error = %v", err)
+		}
+		if decrypted != value {
+			t.Errorf("Decrypt() = %q, want %q", decrypted, value)
+		}
+	}
+}
+
+func TestDecryptErrors(t *testing.T) {
+	key, otherKey := newTestKey(t), newTestKey(t)
+
+	encrypted, err := Encrypt(key, "value")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+
+	// flip a character of the ciphertext keeping valid base64
+	tampered := []byte(encrypted)
+	i := len(EncryptedPrefix) + 20
+	if tampered[i] == 'A' {
+		tampered[i] = 'B'
+	} else {
+		tampered[i] = 'A'
+	}
+
+	tests := []struct {
+		name  string
+		keys  [][]byte
+		value string
+	}{
+		{name: "wrong key", keys: [][]byte{otherKey}, value: encrypted},
+		{name: "tampered", keys: [][]byte{key}, value: string(tampered)},
+		{name: "no prefix", keys: [][]byte{key}, value: "value"},
+		{name: "invalid base64", keys: [][]byte{key}, value: EncryptedPrefix + "!!!"},
+		{name: "too short", keys: [][]byte{key}, value: EncryptedPrefix + "AAAA"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decrypt(tt.keys, tt.value)
+			if !errors.Is(err, ErrDecryptionFailed) {
+				t.Errorf("Decrypt() error = %v, want %v", err, ErrDecryptionFailed)
+			}
+		})
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	key := newTestKey(t)
+	encoded := fmt.Sprintf("# current\n%[1]s\n\n%[1]s,%[1]s\r\n", base64.StdEncoding.EncodeToString(key))
+
+	keys, err := ParseKeys(encoded)
+	if err != nil {
+		t.Fatalf("ParseKeys() error = %v", err)
+	}
+	if len(keys) != 3 {
+		t.Errorf("ParseKeys() returns %d keys, want 3", len(keys))
+	}
+
+	_, err = ParseKeys("c2hvcnQ=")
+	if !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("ParseKeys() error = %v, want %v", err, ErrInvalidKey)
+	}
+
+	_, err = ParseKeys("# no keys\n")
+	if !errors.Is(err, ErrNoEncryptionKey) {
+		t.Errorf("ParseKeys() error = %v, want %v", err, ErrNoEncryptionKey)
+	}
+}
+
+func TestDecryptRotatedKeys(t *testing.T) {
+	oldKey, newKey := newTestKey(t), newTestKey(t)
+
+	encrypted, err := Encrypt(oldKey, "value")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+
+	// the new key goes first after the rotation, the old one still decrypts old values
+	decrypted, err := Decrypt([][]byte{newKey, oldKey}, encrypted)
+	if err != nil {
+		t.Fatalf("Decrypt() error = %v", err)
+	}
+	if decrypted != "value" {
+		t.Errorf("Decrypt() = %q, want %q", decrypted, "value")
+	}
+}
+
+func TestReencrypt(t *testing.T) {
+	oldKey, newKey := newTestKey(t), newTestKey(t)
+
+	password, _ := Encrypt(oldKey, "password")
+	token, _ := Encrypt(oldKey, "token")
+	doc := fmt.Sprintf("# comment\npostgres:\n  password: %s\nredis:\n  password: \"%s\"\n  host: localhost\n", password, token)
+
+	result, n, err := Reencrypt([]byte(doc), [][]byte{oldKey}, newKey)
+	if err != nil {
+		t.Fatalf("Reencrypt() error = %v", err)
+	}
+	if n != 2 {
+		t.Errorf("Reencrypt() replaced %d values, want 2", n)
+	}
+
+	values := encryptedRe.FindAllString(string(result), -1)
+	if len(values) != 2 {
+		t.Fatalf("got %d encrypted values in %q, want 2", len(values), result)
+	}
+
+	for i, want := range []string{"password", "token"} {
+		if _, err = Decrypt([][]byte{oldKey}, values[i]); err == nil {
+			t.Errorf("value %d is still decrypted by the old key", i)
+		}
+
+		got, err := Decrypt([][]byte{newKey}, values[i])
+		if err != nil {
+			t.Fatalf("Decrypt() error = %v", err)
+		}
+		if got != want {
+			t.Errorf("Decrypt() = %q, want %q", got, want)
+		}
+	}
+
+	// the rest of the document is kept intact
+	stripped := encryptedRe.ReplaceAllString(string(result), "<value>")
+	wantStripped := encryptedRe.ReplaceAllString(doc, "<value>")
+	if stripped != wantStripped {
+		t.Errorf("Reencrypt() changed the document:\n%s\nwant:\n%s", stripped, wantStripped)
+	}
+
+	_, n, err = Reencrypt([]byte(doc), [][]byte{newKey}, newKey)
+	if !errors.Is(err, ErrDecryptionFailed) || n != 0 {
+		t.Errorf("Reencrypt() with a wrong key = %d, %v, want 0, %v", n, err, ErrDecryptionFailed)
+	}
+}
+
+func TestLoadDecrypted(t *testing.T) {
+	key := newTestKey(t)
+
+	password, _ := Encrypt(key, "secret-password")
+	doc := fmt.Sprintf(`{"Host": "localhost", "Auth": {"Pass": %q}}`, password)
+
+	cfg := struct {
+		Host string
+		Auth struct{ Pass string }
+	}{}
+	trace := Trace{}
+
+	err := LoadFromReader(strings.NewReader(doc), FormatJSON, &cfg, WithEncryptionKeys(key), WithTrace(&trace))
+	if err != nil {
+		t.Fatalf("LoadFromReader() error = %v", err)
+	}
+	if cfg.Auth.Pass != "secret-password" {
+		t.Errorf("Auth.Pass = %q, want the decrypted value", cfg.Auth.Pass)
+	}
+
+	decrypted := trace.Decrypted()
+	if fmt.Sprint(decrypted) != "[auth.pass]" {
+		t.Errorf("Trace.Decrypted() = %v, want [auth.pass]", decrypted)
+	}
+
+	data, err := Dump(cfg, FormatJSON, decrypted...)
+	if err != nil {
+		t.Fatalf("Dump() error = %v", err)
+	}
+	if strings.Contains(string(data), "secret-password") || !strings.Contains(string(data), "localhost") {
+		t.Errorf("Dump() = %s, want the decrypted value masked", data)
+	}
+
+	err = LoadFromReader(strings.NewReader(doc), FormatJSON, &cfg, WithEncryptionKeys(newTestKey(t)))
+	if !errors.Is(err, ErrDecryptionFailed) {
+		t.Errorf("LoadFromReader() with a wrong key error = %v, want %v", err, ErrDecryptionFailed)
+	}
+}
@@ -0,0 +1,321 @@
+package configloader
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/timmbarton/utils/types/secs"
+)
+
+const envTag = "env"
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	secondsType         = reflect.TypeOf(secs.Seconds(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// applyEnv overrides fields of dest by environment variables. The variable name
+// is taken from the `env` tag, or built from the prefix and the field path when
+// the prefix is not empty. `env:"-"` disables the override for the field.
+func applyEnv(dest any, prefix string, lookup envLookup, trace Trace) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return nil
+	}
+
+	return applyEnvToValue(v.Elem(), prefix, "", lookup, trace)
+}
+
+func applyEnvToValue(v reflect.Value, prefix string, path string, lookup envLookup, trace Trace) error {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag, hasTag := field.Tag.Lookup(envTag)
+		if tag == "-" {
+			continue
+		}
+
+		name := ""
+		switch {
+		case hasTag && tag != "":
+			name = tag
+		case prefix != "":
+			name = prefix + "_" + envName(field)
+		}
+
+		fv := v.Field(i)
+		fieldPath := joinPath(path, fieldName(field))
+
+		if isNested(field.Type) {
+			nestedPrefix, nestedPath := name, fieldPath
+			if field.Anonymous && !hasTag {
+				nestedPrefix, nestedPath = prefix, path
+			}
+
+			if fv.Kind() == reflect.Pointer && fv.IsNil() {
+				err := applyEnvToSection(fv, nestedPrefix, nestedPath, lookup, trace)
+				if err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			err := applyEnvToValue(fv, nestedPrefix, nestedPath, lookup, trace)
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if name == "" {
+			continue
+		}
+
+		value, ok := lookup(name)
+		if !ok {
+			continue
+		}
+
+		err := setFromString(fv, value)
+		if err != nil {
+			return fmt.Errorf("env %s: %w", name, err)
+		}
+
+		trace.replace(fieldPath, "env:"+name)
+	}
+
+	return nil
+}
+
+// applyEnvToSection allocates the nil pointer section when any of its variables is set,
+// the section gets its defaults like sections present in config files do
+func applyEnvToSection(v reflect.Value, prefix string, path string, lookup envLookup, trace Trace) error {
+	section := reflect.New(v.Type().Elem())
+	sectionTrace := Trace{}
+
+	err := applyDefaultsToValue(section, path, sectionTrace)
+	if err != nil {
+		return err
+	}
+
+	err = applyEnvToValue(section, prefix, path, lookup, sectionTrace)
+	if err != nil {
+		return err
+	}
+
+	isSet := false
+	for _, source := range sectionTrace {
+		isSet = isSet || strings.HasPrefix(source, "env:")
+	}
+	if !isSet {
+		return nil
+	}
+
+	v.Set(section)
+	for p, source := range sectionTrace {
+		trace.replace(p, source)
+	}
+
+	return nil
+}
+
+// isNested reports whether the type is a struct which fields are configured separately
+func isNested(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	return t.Kind() == reflect.Struct &&
+		t != reflect.TypeOf(time.Time{}) &&
+		!reflect.PointerTo(t).Implements(textUnmarshalerType) &&
+		!isStdlibType(t)
+}
+
+// mainModule is the path of the main module, its packages are never taken for the standard library
+var mainModule = sync.OnceValue(func() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+
+	return info.Main.Path
+})
+
+// isStdlibType reports whether the type is declared in the standard library, e.g. tls.Config.
+// Such structs are not config sections, their fields get no env variables, flags and defaults.
+func isStdlibType(t reflect.Type) bool {
+	pkg := t.PkgPath()
+	if pkg == "" {
+		return false
+	}
+
+	if m := mainModule(); m != "" && (pkg == m || strings.HasPrefix(pkg, m+"/")) {
+		return false
+	}
+
+	// paths of other modules have a dot in the first element, e.g. github.com/...
+	first, _, _ := strings.Cut(pkg, "/")
+
+	return !strings.Contains(first, ".")
+}
+
+func envName(field reflect.StructField) string {
+	return toScreamingSnake(fieldName(field))
+}
+
+// fieldName returns the json name of the field
+func fieldName(field reflect.StructField) string {
+	if jsonName, _, _ := strings.Cut(field.Tag.Get("json"), ","); jsonName != "" && jsonName != "-" {
+		return jsonName
+	}
+
+	return field.Name
+}
+
+// toScreamingSnake converts names like StartTimeout and TLSConfig into START_TIMEOUT and TLS_CONFIG
+func toScreamingSnake(s string) string {
+	runes := []rune(s)
+	b := strings.Builder{}
+
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+				b.WriteRune('_')
+			}
+		}
+
+		if r == '-' || r == '.' {
+			r = '_'
+		}
+
+		b.WriteRune(unicode.ToUpper(r))
+	}
+
+	return b.String()
+}
+
+// setFromString converts the string value into the field type and sets it
+func setFromString(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+
+		return setFromString(v.Elem(), s)
+	}
+
+	switch v.Type() {
+	case durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+
+		v.SetInt(int64(d))
+
+		return nil
+	case secondsType:
+		d, err := parseSeconds(s)
+		if err != nil {
+			return err
+		}
+
+		v.SetInt(int64(d))
+
+		return nil
+	}
+
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		v.SetFloat(f)
+	case reflect.Slice:
+		parts := []string(nil)
+		if s != "" {
+			parts = strings.Split(s, ",")
+		}
+
+		slice := reflect.MakeSlice(v.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			err := setFromString(slice.Index(i), strings.TrimSpace(part))
+			if err != nil {
+				return err
+			}
+		}
+
+		v.Set(slice)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return nil
+}
+
+// parseSeconds accepts both the number of seconds and the duration string, e.g. "30" or "30s"
+func parseSeconds(s string) (time.Duration, error) {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err == nil {
+		return time.Duration(n) * time.Second, nil
+	}
+
+	return time.ParseDuration(s)
+}
@@ -0,0 +1,277 @@
+package configloader
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/go-playground/validator/v10"
+)
+
+var ErrUnknownField = errors.New("unknown field")
+
+// FieldError describes an error in the config with its location, unknown parts are left empty
+type FieldError struct {
+	Source string // file name or another source of the value like env:APP_HTTP_ADDR
+	Line   int
+	Column int
+	Path   string // dotted field path, e.g. http.addr or ports[1]
+	Err    error
+}
+
+func (e *FieldError) Error() string {
+	b := strings.Builder{}
+
+	if e.Source != "" {
+		b.WriteString(e.Source)
+		if e.Line > 0 {
+			b.WriteString(fmt.Sprintf(":%d:%d", e.Line, e.Column))
+		}
+		b.WriteString(": ")
+	}
+	if e.Path != "" {
+		b.WriteString(e.Path)
+		b.WriteString(": ")
+	}
+
+	b.WriteString(e.Err.Error())
+
+	return b.String()
+}
+func (e *FieldError) Unwrap() error { return e.Err }
+
+func sourceName(name string) string { return strings.TrimPrefix(name, fileSourcePrefix) }
+
+func jsonSyntaxError(data []byte, name string, err error) error {
+	syntaxErr := (*json.SyntaxError)(nil)
+	if errors.As(err, &syntaxErr) {
+		pos := offsetToPosition(data, max(syntaxErr.Offset-1, 0))
+		return &FieldError{Source: sourceName(name), Line: pos.line, Column: pos.column, Err: err}
+	}
+
+	return &FieldError{Source: sourceName(name), Err: err}
+}
+
+func tomlSyntaxError(name string, err error) error {
+	parseErr := toml.ParseError{}
+	if errors.As(err, &parseErr) {
+		return &FieldError{
+			Source: sourceName(name),
+			Line:   parseErr.Position.Line,
+			Column: parseErr.Position.Col,
+			Err:    errors.New(parseErr.Message),
+		}
+	}
+
+	return &FieldError{Source: sourceName(name), Err: err}
+}
+
+// locator finds where the value of the field path came from
+type locator struct {
+	layers []layer
+	trace  Trace
+}
+
+func (l locator) fieldError(path string, err error) *FieldError {
+	fe := &FieldError{Path: path, Err: err}
+
+	source, traced := l.trace[indexRe.ReplaceAllString(path, "")]
+	source = strings.TrimSuffix(source, decryptedSuffix)
+
+	// the position is taken from the layer which set the value, values set
+	// by env, flags or defaults have no layers and no positions
+	for i := len(l.layers) - 1; i >= 0; i-- {
+		if traced && l.layers[i].name != source {
+			continue
+		}
+
+		pos, ok := l.layers[i].positions[path]
+		if ok {
+			fe.Source = sourceName(l.layers[i].name)
+			fe.Line, fe.Column = pos.line, pos.column
+
+			return fe
+		}
+	}
+
+	if traced {
+		fe.Source = sourceName(source)
+	}
+
+	return fe
+}
+
+// decodeError adds the location to the json type errors
+func (l locator) decodeError(err error) error {
+	typeErr := (*json.UnmarshalTypeError)(nil)
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return l.fieldError(
+			strings.ToLower(typeErr.Field),
+			fmt.Errorf("cannot use %s as %s", typeErr.Value, typeErr.Type),
+		)
+	}
+
+	return err
+}
+
+// validationError splits validator errors into field errors with locations
+func (l locator) validationError(dest any, err error) error {
+	validationErrs := validator.ValidationErrors(nil)
+	if !errors.As(err, &validationErrs) {
+		return err
+	}
+
+	errs := make([]error, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		msg := fmt.Sprintf("failed on the '%s' validation", fe.Tag())
+		if fe.Param() != "" {
+			msg = fmt.Sprintf("failed on the '%s=%s' validation", fe.Tag(), fe.Param())
+		}
+
+		errs = append(errs, l.fieldError(namespaceToPath(reflect.TypeOf(dest), fe.StructNamespace()), errors.New(msg)))
+	}
+
+	return errors.Join(errs...)
+}
+
+var (
+	indexRe     = regexp.MustCompile(`\[[^]]*]`)
+	namespaceRe = regexp.MustCompile(`^([^\[]*)(.*)$`)
+)
+
+// namespaceToPath converts validator struct namespace like Config.HTTP.Addr into the path http.addr
+func namespaceToPath(t reflect.Type, namespace string) string {
+	parts := strings.Split(namespace, ".")
+	if len(parts) > 0 {
+		parts = parts[1:] // root type name
+	}
+
+	path := ""
+	for _, part := range parts {
+		m := namespaceRe.FindStringSubmatch(part)
+		name, index := m[1], m[2]
+
+		for t != nil && (t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map) {
+			t = t.Elem()
+		}
+
+		segment := name
+		if t != nil && t.Kind() == reflect.Struct {
+			field, ok := t.FieldByName(name)
+			if ok {
+				segment = fieldName(field)
+				t = field.Type
+			} else {
+				t = nil
+			}
+		}
+
+		path = joinPath(path, segment) + index
+	}
+
+	return path
+}
+
+// unknownFields returns paths of the tree keys which have no matching fields in the type
+func unknownFields(tree any, t reflect.Type, path string) []string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if reflect.PointerTo(t).Implements(reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()) ||
+		reflect.PointerTo(t).Implements(reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()) {
+		return nil
+	}
+
+	unknown := []string(nil)
+
+	switch tree := tree.(type) {
+	case map[string]any:
+		switch t.Kind() {
+		case reflect.Struct:
+			fields := jsonFields(t)
+			for k, v := range tree {
+				fieldType, ok := matchField(fields, k)
+				if !ok {
+					unknown = append(unknown, joinPath(path, k))
+					continue
+				}
+
+				unknown = append(unknown, unknownFields(v, fieldType, joinPath(path, k))...)
+			}
+		case reflect.Map:
+			for k, v := range tree {
+				unknown = append(unknown, unknownFields(v, t.Elem(), joinPath(path, k))...)
+			}
+		}
+	case []any:
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			for i, v := range tree {
+				unknown = append(unknown, unknownFields(v, t.Elem(), indexPath(path, i))...)
+			}
+		}
+	}
+
+	slices.Sort(unknown)
+
+	return unknown
+}
+
+type jsonField struct {
+	name string
+	typ  reflect.Type
+}
+
+// jsonFields returns the fields visible to encoding/json including promoted ones
+func jsonFields(t reflect.Type) []jsonField {
+	fields := make([]jsonField, 0, t.NumField())
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		jsonName, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && jsonName == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				fields = append(fields, jsonFields(ft)...)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		fields = append(fields, jsonField{name: fieldName(field), typ: field.Type})
+	}
+
+	return fields
+}
+
+// matchField finds the field like encoding/json does: the exact name first, then case-insensitively
+func matchField(fields []jsonField, key string) (reflect.Type, bool) {
+	for _, f := range fields {
+		if f.name == key {
+			return f.typ, true
+		}
+	}
+	for _, f := range fields {
+		if strings.EqualFold(f.name, key) {
+			return f.typ, true
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,129 @@
+package configloader
+
+import (
+	"reflect"
+	"strings"
+)
+
+// fieldInfo describes a configurable leaf field of a config struct
+type fieldInfo struct {
+	index []int  // index path for reflect.Value.FieldByIndex
+	path  string // lower-case dotted path, the same as in Trace
+	flag  string // dotted kebab-case flag name
+	field reflect.StructField
+}
+
+// collectFields returns all leaf fields of the struct type which values can be set from strings
+func collectFields(t reflect.Type) []fieldInfo {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	return appendFields(nil, t, nil, "", "", map[reflect.Type]bool{})
+}
+
+func appendFields(
+	fields []fieldInfo,
+	t reflect.Type,
+	index []int,
+	path string,
+	flagName string,
+	seen map[reflect.Type]bool,
+) []fieldInfo {
+	// protect from recursive types
+	if seen[t] {
+		return fields
+	}
+	seen[t] = true
+	defer delete(seen, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() || strings.HasPrefix(field.Tag.Get("json"), "-") {
+			continue
+		}
+
+		fieldIndex := append(append([]int(nil), index...), i)
+		fieldPath := joinPath(path, fieldName(field))
+		fieldFlag := joinFlag(flagName, fieldName(field))
+
+		if isNested(field.Type) {
+			nestedType := field.Type
+			if nestedType.Kind() == reflect.Pointer {
+				nestedType = nestedType.Elem()
+			}
+
+			if field.Anonymous {
+				fields = appendFields(fields, nestedType, fieldIndex, path, flagName, seen)
+			} else {
+				fields = appendFields(fields, nestedType, fieldIndex, fieldPath, fieldFlag, seen)
+			}
+
+			continue
+		}
+
+		if !isSettable(field.Type) {
+			continue
+		}
+
+		fields = append(fields, fieldInfo{
+			index: fieldIndex,
+			path:  fieldPath,
+			flag:  fieldFlag,
+			field: field,
+		})
+	}
+
+	return fields
+}
+
+// isSettable reports whether setFromString supports the type
+func isSettable(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() != reflect.Slice && isSettable(t.Elem()) && !isNested(t.Elem())
+	default:
+		return false
+	}
+}
+
+// fieldByIndex is reflect.Value.FieldByIndex which allocates nil pointers on the way
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for _, i := range index {
+		if v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+
+			v = v.Elem()
+		}
+
+		v = v.Field(i)
+	}
+
+	return v
+}
+
+func joinFlag(flagName string, name string) string {
+	name = strings.ReplaceAll(strings.ToLower(toScreamingSnake(name)), "_", "-")
+	if flagName == "" {
+		return name
+	}
+
+	return flagName + "." + name
+}
@@ -0,0 +1,32 @@
+package configloader
+
+import (
+	"crypto/tls"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCollectFields(t *testing.T) {
+	type db struct {
+		Host      string
+		TLSConfig *tls.Config
+	}
+	cfg := struct {
+		Name    string
+		Started time.Time
+		DB      db
+		Replica *db
+	}{}
+
+	paths := []string(nil)
+	for _, f := range collectFields(reflect.TypeOf(cfg)) {
+		paths = append(paths, f.path+"="+f.flag)
+	}
+
+	want := "[name=name started=started db.host=db.host replica.host=replica.host]"
+	if fmt.Sprint(paths) != want {
+		t.Errorf("collectFields() = %v, want %s", paths, want)
+	}
+}
@@ -0,0 +1,193 @@
+package configloader
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const configPathFlag = "config"
+
+// ErrHelp is returned when the help flag is passed, usage is already printed at this moment
+var ErrHelp = flag.ErrHelp
+
+// ErrFlagCollision is returned when several fields map to the same flag name
+var ErrFlagCollision = errors.New("flag name collision")
+
+// parsedFlags holds flag values in the order of the config fields
+type parsedFlags struct {
+	configPath string
+	values     []flagValue
+}
+
+type flagValue struct {
+	info  fieldInfo
+	value string
+}
+
+// stringValue collects the raw string of the flag, conversion happens later on the loaded config
+type stringValue struct {
+	value  string
+	isBool bool
+}
+
+func (v *stringValue) String() string     { return v.value }
+func (v *stringValue) Set(s string) error { v.value = s; return nil }
+func (v *stringValue) IsBoolFlag() bool   { return v.isBool }
+
+// parseFlags parses args with flags derived from the dest struct fields,
+// e.g. --http.addr for the field HTTP.Addr, and --config for the config file path
+func parseFlags(dest any, args []string, output io.Writer) (*parsedFlags, error) {
+	fields := collectFields(reflect.TypeOf(dest))
+
+	fs := flag.NewFlagSet(programName(), flag.ContinueOnError)
+	fs.SetOutput(output)
+
+	configPath := fs.String(configPathFlag, "", "path to the config file")
+
+	values := make(map[string]*stringValue, len(fields))
+	owners := make(map[string]string, len(fields))
+	for _, f := range fields {
+		// flag.FlagSet panics on redefinition, help flags are handled by the flag package itself
+		if f.flag == configPathFlag || f.flag == "help" || f.flag == "h" {
+			return nil, fmt.Errorf("%w: --%s of %s is reserved", ErrFlagCollision, f.flag, f.path)
+		}
+		if owner, ok := owners[f.flag]; ok {
+			return nil, fmt.Errorf("%w: --%s of %s and %s", ErrFlagCollision, f.flag, owner, f.path)
+		}
+		owners[f.flag] = f.path
+
+		v := &stringValue{isBool: indirectKind(f.field.Type) == reflect.Bool}
+		values[f.flag] = v
+		fs.Var(v, f.flag, "")
+	}
+
+	fs.Usage = func() { printUsage(fs.Output(), fs.Name(), fields, reflect.ValueOf(dest)) }
+
+	err := fs.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	parsed := &parsedFlags{configPath: *configPath}
+	for _, f := range fields {
+		if set[f.flag] {
+			parsed.values = append(parsed.values, flagValue{info: f, value: values[f.flag].value})
+		}
+	}
+
+	return parsed, nil
+}
+
+// applyFlags sets the parsed flag values on dest
+func applyFlags(dest any, parsed *parsedFlags, trace Trace) error {
+	if parsed == nil {
+		return nil
+	}
+
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return nil
+	}
+
+	for _, f := range parsed.values {
+		err := setFromString(fieldByIndex(v.Elem(), f.info.index), f.value)
+		if err != nil {
+			return fmt.Errorf("flag --%s: %w", f.info.flag, err)
+		}
+
+		trace.replace(f.info.path, "flag:--"+f.info.flag)
+	}
+
+	return nil
+}
+
+func printUsage(w io.Writer, name string, fields []fieldInfo, dest reflect.Value) {
+	_, _ = fmt.Fprintf(w, "Usage of %s:\n", name)
+	_, _ = fmt.Fprintf(w, "  --%s string\n    \tpath to the config file\n", configPathFlag)
+
+	for _, f := range fields {
+		b := strings.Builder{}
+		b.WriteString(fmt.Sprintf("  --%s %s\n    \t", f.flag, typeName(f.field.Type)))
+
+		details := make([]string, 0, 2)
+		if def := defaultString(dest, f); def != "" {
+			details = append(details, "default: "+def)
+		}
+		if validate := f.field.Tag.Get("validate"); validate != "" {
+			details = append(details, "validate: "+validate)
+		}
+		if len(details) == 0 {
+			details = append(details, "optional")
+		}
+
+		b.WriteString(strings.Join(details, ", "))
+		b.WriteString("\n")
+
+		_, _ = io.WriteString(w, b.String())
+	}
+}
+
+// defaultString returns the current non-zero value of the field in dest
+func defaultString(dest reflect.Value, f fieldInfo) string {
+	v := dest
+	for _, i := range f.index {
+		for v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return ""
+			}
+
+			v = v.Elem()
+		}
+
+		v = v.Field(i)
+	}
+
+	if v.IsZero() {
+		return ""
+	}
+	if v.Type() == secondsType {
+		return time.Duration(v.Int()).String()
+	}
+
+	return fmt.Sprint(v.Interface())
+}
+
+func typeName(t reflect.Type) string {
+	switch {
+	case t == durationType || t == secondsType:
+		return "duration"
+	case t.Kind() == reflect.Pointer:
+		return typeName(t.Elem())
+	case t.Kind() == reflect.Slice:
+		return "[]" + typeName(t.Elem())
+	case reflect.PointerTo(t).Implements(textUnmarshalerType):
+		return "string"
+	default:
+		return t.Kind().String()
+	}
+}
+
+func indirectKind(t reflect.Type) reflect.Kind {
+	if t.Kind() == reflect.Pointer {
+		return t.Elem().Kind()
+	}
+
+	return t.Kind()
+}
+
+func programName() string {
+	if len(os.Args) == 0 {
+		return "app"
+	}
+
+	return os.Args[0]
+}
@@ -0,0 +1,246 @@
+package configloader
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatYAML Format = "yaml"
+	FormatTOML Format = "toml"
+)
+
+var ErrUnknownFormat = errors.New("unknown config format")
+
+// FormatFromPath picks a config format by the file extension
+func FormatFromPath(filePath string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".json":
+		return FormatJSON, nil
+	case ".yaml", ".yml":
+		return FormatYAML, nil
+	case ".toml":
+		return FormatTOML, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnknownFormat, filePath)
+	}
+}
+
+// decodeLayer decodes data of any supported format into a generic tree
+// of map[string]any, []any and scalar values, remembering positions of the keys
+func decodeLayer(r io.Reader, format Format, name string) (layer, error) {
+	l := layer{name: name, document: true}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return l, err
+	}
+
+	tree := any(nil)
+
+	switch format {
+	case FormatJSON:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+
+		err = dec.Decode(&tree)
+		if err != nil {
+			return l, jsonSyntaxError(data, name, err)
+		}
+
+		l.positions = jsonPositions(data)
+	case FormatYAML:
+		node := yaml.Node{}
+
+		err = yaml.Unmarshal(data, &node)
+		if err != nil {
+			return l, &FieldError{Source: sourceName(name), Err: err}
+		}
+
+		tree, err = yamlTree(&node)
+		if err != nil {
+			return l, &FieldError{Source: sourceName(name), Err: err}
+		}
+
+		l.positions = yamlPositions(&node)
+	case FormatTOML:
+		m := map[string]any(nil)
+
+		_, err = toml.Decode(string(data), &m)
+		if err != nil {
+			return l, tomlSyntaxError(name, err)
+		}
+
+		tree = m
+	default:
+		return l, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+
+	l.tree = normalizeTree(tree)
+
+	return l, nil
+}
+
+// yamlTree converts the yaml node into a generic tree like node.Decode does,
+// but keeps the text of integers and timestamps, so that values like "password: 123456"
+// or "date: 2024-01-01" are decoded into string fields as written
+func yamlTree(node *yaml.Node) (any, error) {
+	switch node.Kind {
+	case 0:
+		return nil, nil
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+
+		return yamlTree(node.Content[0])
+	case yaml.AliasNode:
+		return yamlTree(node.Alias)
+	case yaml.SequenceNode:
+		s := make([]any, len(node.Content))
+		for i, c := range node.Content {
+			item, err := yamlTree(c)
+			if err != nil {
+				return nil, err
+			}
+
+			s[i] = item
+		}
+
+		return s, nil
+	case yaml.MappingNode:
+		m := make(map[string]any, len(node.Content)/2)
+		merges := []*yaml.Node(nil)
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.ShortTag() == "!!merge" {
+				merges = append(merges, value)
+				continue
+			}
+
+			item, err := yamlTree(value)
+			if err != nil {
+				return nil, err
+			}
+
+			if key.Kind == yaml.AliasNode {
+				key = key.Alias
+			}
+			m[key.Value] = item
+		}
+
+		for _, merge := range merges {
+			err := mergeYAML(m, merge)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return m, nil
+	case yaml.ScalarNode:
+		switch node.ShortTag() {
+		case "!!int":
+			if json.Valid([]byte(node.Value)) {
+				return json.Number(node.Value), nil
+			}
+		case "!!timestamp":
+			return node.Value, nil
+		}
+	}
+
+	v := any(nil)
+	err := node.Decode(&v)
+
+	return v, err
+}
+
+// mergeYAML adds the keys of the "<<" merge value to the mapping, keys set explicitly
+// and keys of the earlier merged mappings take precedence
+func mergeYAML(m map[string]any, merge *yaml.Node) error {
+	if merge.Kind == yaml.AliasNode {
+		merge = merge.Alias
+	}
+
+	nodes := []*yaml.Node{merge}
+	if merge.Kind == yaml.SequenceNode {
+		nodes = merge.Content
+	}
+
+	for _, n := range nodes {
+		tree, err := yamlTree(n)
+		if err != nil {
+			return err
+		}
+
+		src, ok := tree.(map[string]any)
+		if !ok {
+			return fmt.Errorf("line %d: map merge requires a map or a sequence of maps", n.Line)
+		}
+
+		for k, v := range src {
+			if _, exists := m[k]; !exists {
+				m[k] = v
+			}
+		}
+	}
+
+	return nil
+}
+
+// normalizeTree converts yaml maps with non-string keys into map[string]any,
+// so that the tree can be encoded as json
+func normalizeTree(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, item := range v {
+			v[k] = normalizeTree(item)
+		}
+
+		return v
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for k, item := range v {
+			m[fmt.Sprint(k)] = normalizeTree(item)
+		}
+
+		return m
+	case []any:
+		for i, item := range v {
+			v[i] = normalizeTree(item)
+		}
+
+		return v
+	case []map[string]any: // toml arrays of tables
+		s := make([]any, len(v))
+		for i, item := range v {
+			s[i] = normalizeTree(item)
+		}
+
+		return s
+	default:
+		return v
+	}
+}
+
+// decodeInto decodes the tree into dest through json, so that all formats
+// respect json tags and custom json unmarshalers of the config types
+func decodeInto(tree any, dest any) error {
+	data, err := json.Marshal(tree)
+	if err != nil {
+		return err
+	}
+
+	return json.NewDecoder(bytes.NewReader(data)).Decode(dest)
+}
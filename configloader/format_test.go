@@ -0,0 +1,104 @@
+package configloader
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type scalarsConfig struct {
+	Password Secret
+	Date     string
+	Version  string
+	Enabled  string
+	Port     int
+	Started  time.Time
+	Nested   struct {
+		Host string
+		Port int
+	}
+}
+
+func TestLoadScalars(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		doc    string
+		check  func(t *testing.T, cfg scalarsConfig)
+	}{
+		{
+			name:   "yaml number into secret",
+			format: FormatYAML,
+			doc:    "password: 123456\n",
+			check: func(t *testing.T, cfg scalarsConfig) {
+				if cfg.Password.Value() != "123456" {
+					t.Errorf("Password = %q, want %q", cfg.Password.Value(), "123456")
+				}
+			},
+		},
+		{
+			name:   "yaml date into string",
+			format: FormatYAML,
+			doc:    "date: 2024-01-01\n",
+			check: func(t *testing.T, cfg scalarsConfig) {
+				if cfg.Date != "2024-01-01" {
+					t.Errorf("Date = %q, want %q", cfg.Date, "2024-01-01")
+				}
+			},
+		},
+		{
+			name:   "yaml date into time",
+			format: FormatYAML,
+			doc:    "started: 2024-01-01\n",
+			check: func(t *testing.T, cfg scalarsConfig) {
+				if want := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC); !cfg.Started.Equal(want) {
+					t.Errorf("Started = %v, want %v", cfg.Started, want)
+				}
+			},
+		},
+		{
+			name:   "yaml float and bool into strings",
+			format: FormatYAML,
+			doc:    "version: 1.10\nenabled: true\nport: 5432\n",
+			check: func(t *testing.T, cfg scalarsConfig) {
+				if cfg.Version != "1.1" || cfg.Enabled != "true" || cfg.Port != 5432 {
+					t.Errorf("Version, Enabled, Port = %q, %q, %d, want 1.1, true, 5432", cfg.Version, cfg.Enabled, cfg.Port)
+				}
+			},
+		},
+		{
+			name:   "yaml merge keys",
+			format: FormatYAML,
+			doc:    "base: &base\n  host: localhost\n  port: 5432\nnested:\n  <<: *base\n  port: 6432\n",
+			check: func(t *testing.T, cfg scalarsConfig) {
+				if cfg.Nested.Host != "localhost" || cfg.Nested.Port != 6432 {
+					t.Errorf("Nested = %+v, want localhost:6432", cfg.Nested)
+				}
+			},
+		},
+		{
+			name:   "toml numbers into strings",
+			format: FormatTOML,
+			doc:    "password = 123456\nversion = 2.5\ndate = 2024-01-01\n",
+			check: func(t *testing.T, cfg scalarsConfig) {
+				if cfg.Password.Value() != "123456" || cfg.Version != "2.5" || cfg.Date != "2024-01-01" {
+					t.Errorf("Password, Version, Date = %q, %q, %q, want 123456, 2.5, 2024-01-01",
+						cfg.Password.Value(), cfg.Version, cfg.Date)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := scalarsConfig{}
+
+			err := LoadFromReader(strings.NewReader(tt.doc), tt.format, &cfg)
+			if err != nil {
+				t.Fatalf("LoadFromReader() error = %v", err)
+			}
+
+			tt.check(t, cfg)
+		})
+	}
+}
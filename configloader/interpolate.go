@@ -0,0 +1,272 @@
+package configloader
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrUnsetVariable     = errors.New("variable is not set")
+	ErrInvalidReference  = errors.New("invalid variable reference")
+	jsonUnmarshalerType  = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	interpolationEscaper = strings.NewReplacer("$${", "${")
+)
+
+// interpolateLayers replaces ${VAR} and ${VAR:-default} in string values of all layers,
+// $${VAR} is kept as the literal ${VAR}. All unset variables are reported at once.
+func interpolateLayers(layers []layer, lookup envLookup) error {
+	errs := []error(nil)
+
+	for i := range layers {
+		l := &layers[i]
+		if !l.document {
+			continue
+		}
+		layerErrs := []*FieldError(nil)
+
+		l.tree = interpolateTree(l.tree, "", lookup, func(path string, err error) {
+			fe := &FieldError{Source: sourceName(l.name), Path: path, Err: err}
+			if pos, ok := l.positions[path]; ok {
+				fe.Line, fe.Column = pos.line, pos.column
+			}
+
+			layerErrs = append(layerErrs, fe)
+		})
+
+		sort.SliceStable(layerErrs, func(i, j int) bool {
+			if layerErrs[i].Line != layerErrs[j].Line {
+				return layerErrs[i].Line < layerErrs[j].Line
+			}
+
+			return layerErrs[i].Column < layerErrs[j].Column
+		})
+
+		for _, err := range layerErrs {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func interpolateTree(tree any, path string, lookup envLookup, onErr func(path string, err error)) any {
+	switch tree := tree.(type) {
+	case map[string]any:
+		for k, v := range tree {
+			tree[k] = interpolateTree(v, joinPath(path, k), lookup, onErr)
+		}
+
+		return tree
+	case []any:
+		for i, v := range tree {
+			tree[i] = interpolateTree(v, indexPath(path, i), lookup, onErr)
+		}
+
+		return tree
+	case string:
+		s, errs := interpolate(tree, lookup)
+		for _, err := range errs {
+			onErr(path, err)
+		}
+
+		return s
+	default:
+		return tree
+	}
+}
+
+// interpolate expands the variable references in s
+func interpolate(s string, lookup envLookup) (string, []error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+
+	b := strings.Builder{}
+	errs := []error(nil)
+
+	for {
+		start := strings.Index(s, "${")
+		if start < 0 {
+			b.WriteString(s)
+			break
+		}
+
+		// escaped reference
+		if start > 0 && s[start-1] == '$' {
+			b.WriteString(interpolationEscaper.Replace(s[:start+2]))
+			s = s[start+2:]
+
+			continue
+		}
+
+		b.WriteString(s[:start])
+
+		end := strings.IndexByte(s[start:], '}')
+		if end < 0 {
+			errs = append(errs, fmt.Errorf("%w: unterminated %q", ErrInvalidReference, s[start:]))
+			b.WriteString(s[start:])
+
+			break
+		}
+
+		expr := s[start+2 : start+end]
+		s = s[start+end+1:]
+
+		name, def, hasDefault := strings.Cut(expr, ":-")
+		if !isVariableName(name) {
+			errs = append(errs, fmt.Errorf("%w: ${%s}", ErrInvalidReference, expr))
+			continue
+		}
+
+		value, ok := lookup(name)
+		switch {
+		case hasDefault && value == "":
+			value = def
+		case !ok:
+			errs = append(errs, fmt.Errorf("%w: %s", ErrUnsetVariable, name))
+		}
+
+		b.WriteString(value)
+	}
+
+	return b.String(), errs
+}
+
+func isVariableName(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for i, r := range name {
+		isLetter := r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+		if !isLetter && (i == 0 || r < '0' || r > '9') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// coerceTree converts strings into numbers and bools where the config field expects them,
+// so that interpolated values like "${PORT:-5432}" can be used for numeric fields
+func coerceTree(tree any, t reflect.Type) any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	custom := reflect.PointerTo(t).Implements(jsonUnmarshalerType)
+	if custom && t != secondsType && t != timeType {
+		return tree
+	}
+
+	switch tree := tree.(type) {
+	case map[string]any:
+		switch t.Kind() {
+		case reflect.Struct:
+			fields := jsonFields(t)
+
+			for k, v := range tree {
+				if fieldType, ok := matchField(fields, k); ok {
+					tree[k] = coerceTree(v, fieldType)
+				}
+			}
+		case reflect.Map:
+			for k, v := range tree {
+				tree[k] = coerceTree(v, t.Elem())
+			}
+		}
+
+		return tree
+	case []any:
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			for i, v := range tree {
+				tree[i] = coerceTree(v, t.Elem())
+			}
+		}
+
+		return tree
+	case string:
+		return coerceString(tree, t)
+	default:
+		if t.Kind() == reflect.String {
+			return scalarText(tree)
+		}
+
+		return tree
+	}
+}
+
+// scalarText converts numbers, bools and dates decoded from yaml or toml into strings,
+// so that e.g. "password = 123456" can be used for string and Secret fields
+func scalarText(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case time.Time:
+		if layout, ok := tomlLocalLayouts[v.Location().String()]; ok {
+			return v.Format(layout)
+		}
+
+		return v.Format(time.RFC3339Nano)
+	default:
+		return v
+	}
+}
+
+// tomlLocalLayouts are the formats of toml local dates and times by the names of their time zones
+var tomlLocalLayouts = map[string]string{
+	"datetime-local": "2006-01-02T15:04:05.999999999",
+	"date-local":     "2006-01-02",
+	"time-local":     "15:04:05.999999999",
+}
+
+// timestampLayouts are the formats of yaml timestamps, which are kept as strings in the tree
+var timestampLayouts = []string{
+	"2006-1-2T15:4:5.999999999Z07:00",
+	"2006-1-2t15:4:5.999999999Z07:00",
+	"2006-1-2 15:4:5.999999999",
+	"2006-1-2",
+}
+
+func coerceString(s string, t reflect.Type) any {
+	if t == timeType {
+		for _, layout := range timestampLayouts {
+			if tm, err := time.Parse(layout, s); err == nil {
+				return tm.Format(time.RFC3339Nano)
+			}
+		}
+
+		return s
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if _, err := strconv.ParseFloat(s, 64); err == nil && json.Valid([]byte(s)) {
+			return json.Number(s)
+		}
+	}
+
+	return s
+}
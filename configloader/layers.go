@@ -0,0 +1,271 @@
+package configloader
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const fileSourcePrefix = "file:"
+
+var ErrInvalidRoot = errors.New("config root is not an object")
+
+// layer is one decoded config source, later layers override earlier ones
+type layer struct {
+	name      string
+	tree      any
+	positions map[string]position // keys positions by paths, nil if unknown
+	document  bool                // decoded from a config document, subject to interpolation
+}
+
+func loadFileLayer(filePath string) (layer, error) {
+	format, err := FormatFromPath(filePath)
+	if err != nil {
+		return layer{}, err
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return layer{}, err
+	}
+	defer file.Close()
+
+	return decodeLayer(file, format, fileSourcePrefix+filePath)
+}
+
+// overlayPaths returns paths of the optional overlays of the base config file:
+// config.json -> config.<env>.json, config.local.json
+func overlayPaths(basePath string, env string) []string {
+	ext := filepath.Ext(basePath)
+	stem := strings.TrimSuffix(basePath, ext)
+
+	paths := make([]string, 0, 2)
+	if env != "" {
+		paths = append(paths, stem+"."+env+ext)
+	}
+
+	return append(paths, stem+".local"+ext)
+}
+
+// layerPaths returns the files to be merged in order: the fragments of the directory
+// or the base config file with its existing overlays
+func layerPaths(basePath string, env string) ([]string, error) {
+	info, err := os.Stat(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		return fragmentPaths(basePath)
+	}
+
+	paths := []string{basePath}
+	for _, overlayPath := range overlayPaths(basePath, env) {
+		_, err = os.Stat(overlayPath)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		paths = append(paths, overlayPath)
+	}
+
+	return paths, nil
+}
+
+// fragmentPaths returns config files of the directory (conf.d) in lexical order,
+// hidden entries like ..data of kubernetes volumes and unknown extensions are skipped
+func fragmentPaths(dirPath string) ([]string, error) {
+	entries, err := os.ReadDir(dirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		_, err = FormatFromPath(entry.Name())
+		if err != nil {
+			continue
+		}
+
+		fragmentPath := filepath.Join(dirPath, entry.Name())
+
+		// stat follows symlinks of mounted configmaps and secrets
+		info, err := os.Stat(fragmentPath)
+		if err != nil {
+			return nil, err
+		}
+		if !info.Mode().IsRegular() {
+			continue
+		}
+
+		paths = append(paths, fragmentPath)
+	}
+
+	slices.Sort(paths)
+
+	return paths, nil
+}
+
+// loadLayers loads the files returned by layerPaths
+func loadLayers(basePath string, env string) ([]layer, error) {
+	paths, err := layerPaths(basePath, env)
+	if err != nil {
+		return nil, err
+	}
+
+	return loadFileLayers(paths)
+}
+
+func loadFileLayers(paths []string) ([]layer, error) {
+	layers := make([]layer, 0, len(paths))
+	for _, p := range paths {
+		l, err := loadFileLayer(p)
+		if err != nil {
+			return nil, err
+		}
+
+		layers = append(layers, l)
+	}
+
+	return layers, nil
+}
+
+// mergeLayers deep merges the layers, objects are merged key by key
+// while scalars and arrays are replaced. Empty layers are skipped,
+// so that an empty overlay doesn't drop the layers before it.
+func mergeLayers(layers []layer, trace Trace) (any, error) {
+	merged := any(nil)
+	for _, l := range layers {
+		if l.tree == nil {
+			continue
+		}
+
+		if _, ok := l.tree.(map[string]any); !ok {
+			return nil, fmt.Errorf("%s: %w", l.name, ErrInvalidRoot)
+		}
+
+		merged = mergeTree(merged, l.tree, "", l.name, trace)
+	}
+
+	return merged, nil
+}
+
+func mergeTree(dst any, src any, path string, source string, trace Trace) any {
+	srcMap, srcIsMap := src.(map[string]any)
+	dstMap, dstIsMap := dst.(map[string]any)
+
+	if !srcIsMap {
+		trace.replace(path, source)
+		return src
+	}
+	if !dstIsMap {
+		if dst != nil {
+			trace.replace(path, "")
+		}
+		dstMap = make(map[string]any, len(srcMap))
+	}
+
+	for k, v := range srcMap {
+		// keys are matched case-insensitively like json does with struct fields
+		key := k
+		for existing := range dstMap {
+			if strings.EqualFold(existing, k) {
+				key = existing
+				break
+			}
+		}
+
+		dstMap[key] = mergeTree(dstMap[key], v, joinPath(path, k), source, trace)
+	}
+
+	return dstMap
+}
+
+func joinPath(path string, key string) string {
+	key = strings.ToLower(key)
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
+
+// Trace maps lower-case dotted field paths (e.g. "http.addr")
+// to the sources of their effective values
+type Trace map[string]string
+
+// replace sets the source of the path and drops the sources of its former children
+func (t Trace) replace(path string, source string) {
+	if t == nil {
+		return
+	}
+
+	for p := range t {
+		if p == path || strings.HasPrefix(p, path+".") || path == "" {
+			delete(t, p)
+		}
+	}
+
+	if source != "" && path != "" {
+		t[path] = source
+	}
+}
+
+// decryptedSuffix marks the sources of decrypted values in the trace
+const decryptedSuffix = " (decrypted)"
+
+// markDecrypted marks the source of the decrypted value, items of arrays mark the whole array
+// as arrays are traced as a whole
+func (t Trace) markDecrypted(path string) {
+	if i := strings.IndexByte(path, '['); i >= 0 {
+		path = path[:i]
+	}
+
+	source, ok := t[path]
+	if ok && !strings.HasSuffix(source, decryptedSuffix) {
+		t[path] = source + decryptedSuffix
+	}
+}
+
+// Decrypted returns the paths of the decrypted values which are not overridden
+// by env variables or flags, pass them to Dump and Diff to mask the values
+func (t Trace) Decrypted() []string {
+	paths := []string(nil)
+	for p, source := range t {
+		if strings.HasSuffix(source, decryptedSuffix) {
+			paths = append(paths, p)
+		}
+	}
+	slices.Sort(paths)
+
+	return paths
+}
+
+func (t Trace) String() string {
+	paths := make([]string, 0, len(t))
+	for p := range t {
+		paths = append(paths, p)
+	}
+	slices.Sort(paths)
+
+	b := strings.Builder{}
+	for _, p := range paths {
+		b.WriteString(p)
+		b.WriteString(" <- ")
+		b.WriteString(t[p])
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
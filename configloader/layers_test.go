@@ -0,0 +1,152 @@
+package configloader
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestMergeLayers(t *testing.T) {
+	tests := []struct {
+		name    string
+		layers  []layer
+		want    string
+		trace   string
+		wantErr error
+	}{
+		{
+			name: "deep merge",
+			layers: []layer{
+				{name: "base", tree: map[string]any{"http": map[string]any{"addr": ":80", "timeout": 5}, "tags": []any{"a"}}},
+				{name: "local", tree: map[string]any{"HTTP": map[string]any{"Addr": ":8080"}, "tags": []any{"b"}}},
+			},
+			want:  "map[http:map[addr::8080 timeout:5] tags:[b]]",
+			trace: "map[http.addr:local http.timeout:base tags:local]",
+		},
+		{
+			name: "object replaces scalar",
+			layers: []layer{
+				{name: "base", tree: map[string]any{"db": "postgres://"}},
+				{name: "local", tree: map[string]any{"db": map[string]any{"host": "localhost"}}},
+			},
+			want:  "map[db:map[host:localhost]]",
+			trace: "map[db.host:local]",
+		},
+		{
+			name: "empty layer",
+			layers: []layer{
+				{name: "base", tree: map[string]any{"a": 1}},
+				{name: "empty"},
+			},
+			want:  "map[a:1]",
+			trace: "map[a:base]",
+		},
+		{
+			name: "not an object",
+			layers: []layer{
+				{name: "base", tree: map[string]any{"a": 1}},
+				{name: "list", tree: []any{1}},
+			},
+			wantErr: ErrInvalidRoot,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trace := Trace{}
+
+			got, err := mergeLayers(tt.layers, trace)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("mergeLayers() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if fmt.Sprint(got) != tt.want {
+				t.Errorf("mergeLayers() = %v, want %s", got, tt.want)
+			}
+			if fmt.Sprint(map[string]string(trace)) != tt.trace {
+				t.Errorf("trace = %v, want %s", map[string]string(trace), tt.trace)
+			}
+		})
+	}
+}
+
+func TestLoadLayered(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "config.json")
+
+	writeFile(t, base, `{"Name": "base", "DB": {"Host": "db", "Port": 5432}}`)
+	writeFile(t, filepath.Join(dir, "config.prod.json"), `{"DB": {"Host": "prod-db"}}`)
+	writeFile(t, filepath.Join(dir, "config.local.yaml"), "name: ignored\n")
+	writeFile(t, filepath.Join(dir, "config.local.json"), `{"Name": "local"}`)
+
+	type config struct {
+		Name string
+		DB   struct {
+			Host string
+			Port int
+		}
+	}
+
+	tests := []struct {
+		env   string
+		want  string
+		order string
+	}{
+		{env: "prod", want: "{local {prod-db 5432}}", order: "[config.json config.prod.json config.local.json]"},
+		{env: "dev", want: "{local {db 5432}}", order: "[config.json config.local.json]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			cfg, order := config{}, []string(nil)
+
+			err := LoadLayered(base, &cfg, WithEnvironment(tt.env), WithMergeOrder(&order))
+			if err != nil {
+				t.Fatalf("LoadLayered() error = %v", err)
+			}
+
+			if fmt.Sprint(cfg) != tt.want {
+				t.Errorf("LoadLayered() = %v, want %s", cfg, tt.want)
+			}
+
+			names := make([]string, len(order))
+			for i, o := range order {
+				names[i] = filepath.Base(o)
+			}
+			if fmt.Sprint(names) != tt.order {
+				t.Errorf("merge order = %v, want %s", names, tt.order)
+			}
+		})
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFile(t, filepath.Join(dir, "10-db.yaml"), "db:\n  host: db\n  port: 5432\n")
+	writeFile(t, filepath.Join(dir, "00-base.json"), `{"Name": "base", "DB": {"Host": "base-db"}}`)
+	writeFile(t, filepath.Join(dir, "99-local.toml"), "name = \"local\"\n")
+	writeFile(t, filepath.Join(dir, ".hidden.json"), `{"Name": "hidden"}`)
+	writeFile(t, filepath.Join(dir, "README.md"), "# fragments\n")
+
+	cfg := struct {
+		Name string
+		DB   struct {
+			Host string
+			Port int
+		}
+	}{}
+
+	err := LoadDir(dir, &cfg)
+	if err != nil {
+		t.Fatalf("LoadDir() error = %v", err)
+	}
+
+	if fmt.Sprint(cfg) != "{local {db 5432}}" {
+		t.Errorf("LoadDir() = %v, want {local {db 5432}}", cfg)
+	}
+}
@@ -0,0 +1,133 @@
+package configloader
+
+import (
+	"io"
+	"os"
+)
+
+type options struct {
+	envPrefix   string
+	environment string
+	trace       *Trace
+	order       *[]string
+	noRefs      bool
+	strict      bool
+	logDump     bool
+	noInterp    bool
+	dotEnvPaths []string
+
+	encryptionKeys    [][]byte
+	encryptionKeyFile string
+	noDecrypt         bool
+
+	args        []string
+	flagsOutput io.Writer
+	flags       *parsedFlags
+}
+
+type Option func(o *options)
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		environment: os.Getenv("ENV"),
+		flagsOutput: os.Stderr,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// WithEnvPrefix enables automatic env overrides for every config field,
+// e.g. field HTTP.Addr is overridden by APP_HTTP_ADDR with prefix "APP".
+// Fields with the `env` tag are overridden regardless of this option.
+func WithEnvPrefix(prefix string) Option {
+	return func(o *options) { o.envPrefix = prefix }
+}
+
+// WithEnvironment sets the environment which overlay is loaded, the default is the ENV variable
+func WithEnvironment(env string) Option {
+	return func(o *options) { o.environment = env }
+}
+
+// WithTrace fills t with the sources of the effective config values
+func WithTrace(t *Trace) Option {
+	return func(o *options) { o.trace = t }
+}
+
+// WithMergeOrder fills order with the sources in the order they were merged,
+// the later ones override the earlier ones
+func WithMergeOrder(order *[]string) Option {
+	return func(o *options) { o.order = order }
+}
+
+// WithStrict rejects config keys which don't match any field of the config struct
+func WithStrict() Option {
+	return func(o *options) { o.strict = true }
+}
+
+// WithDumpLog writes the effective config with masked secrets to the debug log after loading
+func WithDumpLog() Option {
+	return func(o *options) { o.logDump = true }
+}
+
+// WithoutInterpolation disables expanding of ${VAR} and ${VAR:-default} in config files
+func WithoutInterpolation() Option {
+	return func(o *options) { o.noInterp = true }
+}
+
+// WithoutSecretRefs disables resolving of file:// and env:// references in string fields
+func WithoutSecretRefs() Option {
+	return func(o *options) { o.noRefs = true }
+}
+
+// WithFlags enables command-line flags derived from the config struct, see WithArgs
+func WithFlags() Option {
+	return WithArgs(os.Args[1:])
+}
+
+// WithArgs parses args with flags derived from the config struct, e.g. --http.addr,
+// and applies them on top of file and env values. The --config flag overrides
+// CONFIG_FILE_PATH in Load. The --help flag prints usage and makes Load return ErrHelp.
+func WithArgs(args []string) Option {
+	return func(o *options) {
+		o.args = args
+		if o.args == nil {
+			o.args = []string{}
+		}
+	}
+}
+
+// WithUsageOutput sets the writer for the flags usage and errors, os.Stderr by default
+func WithUsageOutput(w io.Writer) Option {
+	return func(o *options) { o.flagsOutput = w }
+}
+
+// parseFlags parses the flags once if they are enabled
+func (o *options) parseFlags(dest any) error {
+	if o.args == nil || o.flags != nil {
+		return nil
+	}
+
+	parsed, err := parseFlags(dest, o.args, o.flagsOutput)
+	if err != nil {
+		return err
+	}
+
+	o.flags = parsed
+
+	return nil
+}
+
+// getTrace returns the trace requested by WithTrace or a new one
+func (o *options) getTrace() Trace {
+	if o.trace == nil {
+		o.trace = new(Trace)
+	}
+	if *o.trace == nil {
+		*o.trace = Trace{}
+	}
+
+	return *o.trace
+}
@@ -0,0 +1,124 @@
+package configloader
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+type position struct {
+	line   int
+	column int
+}
+
+// jsonPositions returns positions of all keys and array items of the json document
+func jsonPositions(data []byte) map[string]position {
+	positions := map[string]position{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	var walk func(path string) error
+	walk = func(path string) error {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+
+		switch tok {
+		case json.Delim('{'):
+			for dec.More() {
+				offset := dec.InputOffset()
+
+				key, err := dec.Token()
+				if err != nil {
+					return err
+				}
+
+				keyPath := joinPath(path, fmt.Sprint(key))
+				positions[keyPath] = offsetToPosition(data, offset)
+
+				err = walk(keyPath)
+				if err != nil {
+					return err
+				}
+			}
+
+			_, err = dec.Token()
+			return err
+		case json.Delim('['):
+			for i := 0; dec.More(); i++ {
+				itemPath := indexPath(path, i)
+				positions[itemPath] = offsetToPosition(data, dec.InputOffset())
+
+				err = walk(itemPath)
+				if err != nil {
+					return err
+				}
+			}
+
+			_, err = dec.Token()
+			return err
+		}
+
+		return nil
+	}
+
+	_ = walk("")
+
+	return positions
+}
+
+// offsetToPosition skips separators after the offset and converts it into the line and column
+func offsetToPosition(data []byte, offset int64) position {
+	i := int(offset)
+	for i < len(data) && bytes.IndexByte([]byte(" \t\r\n,:"), data[i]) >= 0 {
+		i++
+	}
+	if i > len(data) {
+		i = len(data)
+	}
+
+	lineStart := bytes.LastIndexByte(data[:i], '\n') + 1
+
+	return position{
+		line:   bytes.Count(data[:i], []byte{'\n'}) + 1,
+		column: i - lineStart + 1,
+	}
+}
+
+// yamlPositions returns positions of all keys and sequence items of the yaml document
+func yamlPositions(node *yaml.Node) map[string]position {
+	positions := map[string]position{}
+
+	var walk func(n *yaml.Node, path string)
+	walk = func(n *yaml.Node, path string) {
+		switch n.Kind {
+		case yaml.DocumentNode:
+			for _, c := range n.Content {
+				walk(c, path)
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				key, value := n.Content[i], n.Content[i+1]
+				keyPath := joinPath(path, key.Value)
+
+				positions[keyPath] = position{line: key.Line, column: key.Column}
+				walk(value, keyPath)
+			}
+		case yaml.SequenceNode:
+			for i, c := range n.Content {
+				itemPath := indexPath(path, i)
+
+				positions[itemPath] = position{line: c.Line, column: c.Column}
+				walk(c, itemPath)
+			}
+		}
+	}
+
+	walk(node, "")
+
+	return positions
+}
+
+func indexPath(path string, i int) string { return fmt.Sprintf("%s[%d]", path, i) }
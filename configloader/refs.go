@@ -0,0 +1,99 @@
+package configloader
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+const (
+	fileRefPrefix = "file://"
+	envRefPrefix  = "env://"
+)
+
+var ErrUnresolvedRef = errors.New("unresolved secret reference")
+
+// resolveRefs replaces string values like file:///run/secrets/db and env://DB_PASSWORD
+// with the file content and the variable value respectively
+func resolveRefs(dest any, lookup envLookup) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return nil
+	}
+
+	return resolveRefsInValue(v.Elem(), "", lookup)
+}
+
+func resolveRefsInValue(v reflect.Value, path string, lookup envLookup) error {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+
+		return resolveRefsInValue(v.Elem(), path, lookup)
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			fieldPath := joinPath(path, fieldName(field))
+			if field.Anonymous {
+				fieldPath = path
+			}
+
+			err := resolveRefsInValue(v.Field(i), fieldPath, lookup)
+			if err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			err := resolveRefsInValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i), lookup)
+			if err != nil {
+				return err
+			}
+		}
+	case reflect.String:
+		if !v.CanSet() {
+			return nil
+		}
+
+		resolved, err := resolveRef(v.String(), lookup)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		v.SetString(resolved)
+	}
+
+	return nil
+}
+
+func resolveRef(s string, lookup envLookup) (string, error) {
+	switch {
+	case strings.HasPrefix(s, fileRefPrefix):
+		data, err := os.ReadFile(strings.TrimPrefix(s, fileRefPrefix))
+		if err != nil {
+			return "", fmt.Errorf("%w: %w", ErrUnresolvedRef, err)
+		}
+
+		return strings.TrimRight(string(data), "\r\n"), nil
+	case strings.HasPrefix(s, envRefPrefix):
+		name := strings.TrimPrefix(s, envRefPrefix)
+
+		value, ok := lookup(name)
+		if !ok {
+			return "", fmt.Errorf("%w: %s is not set", ErrUnresolvedRef, s)
+		}
+
+		return value, nil
+	default:
+		return s, nil
+	}
+}
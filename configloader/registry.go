@@ -0,0 +1,52 @@
+package configloader
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]func() any{}
+)
+
+// Register makes the config type available by name for tools like the layout command,
+// newConfig must return a pointer to a new config. It panics if the name is already registered.
+func Register(name string, newConfig func() any) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, ok := registry[name]; ok {
+		panic(fmt.Sprintf("configloader: config type %q is already registered", name))
+	}
+
+	registry[name] = newConfig
+}
+
+// NewRegistered returns a pointer to a new config of the registered type
+func NewRegistered(name string) (any, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	newConfig, ok := registry[name]
+	if !ok {
+		return nil, false
+	}
+
+	return newConfig(), true
+}
+
+// Registered returns the sorted names of the registered config types
+func Registered() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names
+}
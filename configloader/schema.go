@@ -0,0 +1,303 @@
+package configloader
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const schemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Schema generates the JSON Schema of the config struct. Types, required fields,
+// min/max limits and defaults come from the field types and the validate and default tags.
+// Property names are matched case-insensitively by patternProperties like the loader does,
+// properties list the names of the fields for completion in editors.
+func Schema(cfg any) ([]byte, error) {
+	t := reflect.TypeOf(cfg)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	s := map[string]any{"$schema": schemaDraft}
+	if t != nil {
+		s["title"] = t.Name()
+		for k, v := range typeSchema(t, map[reflect.Type]bool{}) {
+			s[k] = v
+		}
+	}
+
+	return json.MarshalIndent(s, "", "  ")
+}
+
+func typeSchema(t reflect.Type, seen map[reflect.Type]bool) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t {
+	case secondsType:
+		return map[string]any{"type": "integer", "minimum": 0, "description": "seconds"}
+	case durationType:
+		return map[string]any{"type": "integer", "description": "nanoseconds"}
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case secretType:
+		return map[string]any{"type": "string", "writeOnly": true}
+	}
+
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return map[string]any{"type": "string"}
+	}
+	if reflect.PointerTo(t).Implements(reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()) {
+		return map[string]any{}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		bits := t.Bits()
+		return map[string]any{
+			"type":    "integer",
+			"minimum": int64(-1) << (bits - 1),
+			"maximum": int64(1)<<(bits-1) - 1,
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		s := map[string]any{"type": "integer", "minimum": 0}
+		if t.Bits() < 64 {
+			s["maximum"] = uint64(1)<<t.Bits() - 1
+		}
+
+		return s
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		s := map[string]any{"type": "array", "items": typeSchema(t.Elem(), seen)}
+		if t.Kind() == reflect.Array {
+			s["minItems"], s["maxItems"] = t.Len(), t.Len()
+		}
+
+		return s
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": typeSchema(t.Elem(), seen)}
+	case reflect.Struct:
+		// recursive types are not described deeper than the first level
+		if seen[t] {
+			return map[string]any{"type": "object"}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+
+		properties, required := map[string]any{}, []string{}
+		structSchema(t, seen, properties, &required)
+
+		patterns := make(map[string]any, len(properties))
+		for name, p := range properties {
+			patterns[caseInsensitivePattern(name)] = p
+		}
+
+		s := map[string]any{
+			"type":                 "object",
+			"properties":           properties,
+			"patternProperties":    patterns,
+			"additionalProperties": false,
+		}
+		if len(required) > 0 {
+			s["allOf"] = requiredSchemas(required)
+		}
+
+		return s
+	default:
+		return map[string]any{}
+	}
+}
+
+func structSchema(t reflect.Type, seen map[reflect.Type]bool, properties map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if strings.HasPrefix(field.Tag.Get("json"), "-") || !isDumpable(field.Type) {
+			continue
+		}
+
+		jsonName, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if field.Anonymous && jsonName == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				structSchema(ft, seen, properties, required)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		name := fieldName(field)
+		s := typeSchema(field.Type, seen)
+
+		isRequired := applyValidateTag(s, field.Tag.Get("validate"))
+
+		// fields with defaults may be omitted in files
+		def, hasDefault := field.Tag.Lookup(defaultTag)
+		if isRequired && !hasDefault {
+			*required = append(*required, name)
+		}
+
+		// defaults are rendered like values of config files, e.g. durations in nanoseconds
+		if hasDefault {
+			v := reflect.New(field.Type).Elem()
+			if setFromString(v, def) == nil {
+				if value, err := treeValue(v); err == nil && value != nil {
+					s["default"] = value
+				}
+			}
+		}
+
+		properties[name] = s
+	}
+}
+
+// applyValidateTag adds limits from the validate tag to the schema and reports whether the field is required
+func applyValidateTag(s map[string]any, tag string) (required bool) {
+	if tag == "" {
+		return false
+	}
+
+	target, dived := s, false
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+
+		switch name {
+		case "required":
+			required = required || !dived
+			if _, ok := target["minLength"]; !ok && target["type"] == "string" {
+				target["minLength"] = int64(1)
+			}
+		case "dive":
+			// the following rules describe the items
+			items, ok := target["items"].(map[string]any)
+			if !ok {
+				items, ok = target["additionalProperties"].(map[string]any)
+			}
+			if !ok {
+				return required
+			}
+
+			target, dived = items, true
+		case "min", "gte":
+			setLimit(target, "min", param)
+		case "max", "lte":
+			setLimit(target, "max", param)
+		case "gt":
+			setLimit(target, "exclusiveMin", param)
+		case "lt":
+			setLimit(target, "exclusiveMax", param)
+		case "len":
+			setLimit(target, "min", param)
+			setLimit(target, "max", param)
+		case "oneof":
+			values := []any{}
+			for _, value := range strings.Fields(param) {
+				values = append(values, typedValue(target, value))
+			}
+
+			target["enum"] = values
+		case "email", "uri", "hostname", "ipv4", "ipv6", "uuid":
+			target["format"] = name
+		case "url":
+			target["format"] = "uri"
+		}
+	}
+
+	return required
+}
+
+// caseInsensitivePattern returns the regular expression matching the name in any case, e.g. ^[Hh][Oo][Ss][Tt]$
+func caseInsensitivePattern(name string) string {
+	b := strings.Builder{}
+	b.WriteString("^")
+	for _, r := range name {
+		lower, upper := unicode.ToLower(r), unicode.ToUpper(r)
+		if lower == upper {
+			b.WriteString(regexp.QuoteMeta(string(r)))
+			continue
+		}
+
+		b.WriteString("[" + string(upper) + string(lower) + "]")
+	}
+	b.WriteString("$")
+
+	return b.String()
+}
+
+// requiredSchemas require the properties in any case: the keyword "required"
+// is case-sensitive, so each schema fails when no property name matches the pattern
+func requiredSchemas(required []string) []any {
+	schemas := make([]any, 0, len(required))
+	for _, name := range required {
+		schemas = append(schemas, map[string]any{
+			"not": map[string]any{
+				"propertyNames": map[string]any{"not": map[string]any{"pattern": caseInsensitivePattern(name)}},
+			},
+		})
+	}
+
+	return schemas
+}
+
+// setLimit sets minimum/maximum, minLength/maxLength or minItems/maxItems depending on the schema type
+func setLimit(s map[string]any, limit string, param string) {
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+
+	keys := map[string]string{}
+	switch s["type"] {
+	case "integer", "number":
+		keys = map[string]string{"min": "minimum", "max": "maximum", "exclusiveMin": "exclusiveMinimum", "exclusiveMax": "exclusiveMaximum"}
+	case "string":
+		keys = map[string]string{"min": "minLength", "max": "maxLength"}
+	case "array":
+		keys = map[string]string{"min": "minItems", "max": "maxItems"}
+	case "object":
+		keys = map[string]string{"min": "minProperties", "max": "maxProperties"}
+	}
+
+	key, ok := keys[limit]
+	if !ok {
+		return
+	}
+
+	if n == math.Trunc(n) {
+		s[key] = int64(n)
+	} else {
+		s[key] = n
+	}
+}
+
+func typedValue(s map[string]any, value string) any {
+	switch s["type"] {
+	case "integer":
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+
+	return value
+}
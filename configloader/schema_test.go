@@ -0,0 +1,50 @@
+package configloader
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/timmbarton/utils/types/secs"
+)
+
+func TestSchemaDefaults(t *testing.T) {
+	cfg := struct {
+		Timeout time.Duration `default:"1m"`
+		Stop    secs.Seconds  `default:"30s"`
+		Name    string        `default:"app"`
+		Ports   []int         `default:"80,443"`
+	}{}
+
+	data, err := Schema(cfg)
+	if err != nil {
+		t.Fatalf("Schema() error = %v", err)
+	}
+
+	s := struct {
+		Properties map[string]struct {
+			Type    string
+			Default any
+		}
+	}{}
+
+	err = json.Unmarshal(data, &s)
+	if err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	// defaults are described like the values of config files
+	want := map[string]string{
+		"Timeout": "integer 6e+10",
+		"Stop":    "integer 30",
+		"Name":    "string app",
+		"Ports":   "array [80 443]",
+	}
+	for name, w := range want {
+		p := s.Properties[name]
+		if got := fmt.Sprintf("%s %v", p.Type, p.Default); got != w {
+			t.Errorf("%s = %s, want %s", name, got, w)
+		}
+	}
+}
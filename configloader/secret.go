@@ -0,0 +1,7 @@
+package configloader
+
+import "github.com/timmbarton/layout/secret"
+
+// Secret is a string which decodes normally but never shows its value,
+// see secret.Secret
+type Secret = secret.Secret
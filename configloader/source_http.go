@@ -0,0 +1,141 @@
+package configloader
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+)
+
+// HTTPSource reads a json or yaml document from the HTTP endpoint of a key/value store.
+// Flat keys like "http/addr" are expanded into nested objects by Separator.
+type HTTPSource struct {
+	URL       string
+	Header    http.Header
+	Client    *http.Client  // http.DefaultClient if nil
+	Separator string        // "/" if empty
+	Interval  time.Duration // polling interval of Watch, DefaultWatchInterval if zero
+}
+
+func (s HTTPSource) Name() string { return "http:" + s.URL }
+
+func (s HTTPSource) Read(ctx context.Context, dest any) (map[string]any, error) {
+	l, err := s.readLayer(ctx, dest)
+	if err != nil {
+		return nil, err
+	}
+
+	m, _ := l.tree.(map[string]any)
+
+	return m, nil
+}
+
+func (s HTTPSource) readLayer(ctx context.Context, _ any) (layer, error) {
+	data, contentType, _, err := s.get(ctx, "")
+	if err != nil {
+		return layer{}, err
+	}
+
+	format := FormatJSON
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType == "application/yaml" ||
+		mediaType == "application/x-yaml" || mediaType == "text/yaml" {
+		format = FormatYAML
+	}
+
+	l, err := decodeLayer(bytes.NewReader(data), format, s.Name())
+	if err != nil {
+		return layer{}, err
+	}
+
+	if m, ok := l.tree.(map[string]any); ok {
+		sep := s.Separator
+		if sep == "" {
+			sep = "/"
+		}
+
+		l.tree = expandTree(m, sep)
+	}
+
+	return l, nil
+}
+
+// Watch polls the endpoint with If-None-Match and calls onChange when the document changes,
+// the first fetched document is reported as a change too since it may differ from the loaded one
+func (s HTTPSource) Watch(ctx context.Context, onChange func()) error {
+	interval := s.Interval
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	etag, hash := "", [sha256.Size]byte{}
+
+	for {
+		data, _, newEtag, err := s.get(ctx, etag)
+
+		// skip unavailable store and not modified document
+		if err == nil && data != nil {
+			newHash := sha256.Sum256(data)
+			if newHash != hash {
+				onChange()
+			}
+
+			etag, hash = newEtag, newHash
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// get returns nil data if the document is not modified since etag
+func (s HTTPSource) get(ctx context.Context, etag string) (data []byte, contentType string, newEtag string, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	for k, values := range s.Header {
+		for _, v := range values {
+			req.Header.Add(k, v)
+		}
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", "", err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		return nil, "", etag, nil
+	default:
+		return nil, "", "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	data, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	return data, resp.Header.Get("Content-Type"), resp.Header.Get("ETag"), nil
+}
@@ -0,0 +1,156 @@
+package configloader
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHTTPSourceRead(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		separator   string
+		want        map[string]any
+	}{
+		{
+			name:        "json",
+			contentType: "application/json",
+			body:        `{"http/addr": ":8080", "log": {"level": "debug"}}`,
+			want: map[string]any{
+				"http": map[string]any{"addr": ":8080"},
+				"log":  map[string]any{"level": "debug"},
+			},
+		},
+		{
+			name:        "yaml",
+			contentType: "application/yaml; charset=utf-8",
+			body:        "http/addr: \":8080\"\nhttp/readtimeout: 5\n",
+			want: map[string]any{
+				"http": map[string]any{"addr": ":8080", "readtimeout": 5},
+			},
+		},
+		{
+			name:        "custom separator",
+			contentType: "text/yaml",
+			body:        "http.addr: \":8080\"\n",
+			separator:   ".",
+			want: map[string]any{
+				"http": map[string]any{"addr": ":8080"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("X-Token") != "token" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+
+				w.Header().Set("Content-Type", tt.contentType)
+				_, _ = fmt.Fprint(w, tt.body)
+			}))
+			defer srv.Close()
+
+			s := HTTPSource{URL: srv.URL, Header: http.Header{"X-Token": {"token"}}, Separator: tt.separator}
+
+			got, err := s.Read(context.Background(), nil)
+			if err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("Read() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPSourceReadStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	_, err := HTTPSource{URL: srv.URL}.Read(context.Background(), nil)
+	if err == nil {
+		t.Fatal("Read() error = nil, want unexpected status")
+	}
+}
+
+func TestHTTPSourceWatch(t *testing.T) {
+	version, notModified := atomic.Int64{}, atomic.Int64{}
+	version.Store(1)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		etag := fmt.Sprintf(`"v%d"`, version.Load())
+		if r.Header.Get("If-None-Match") == etag {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+
+			return
+		}
+
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"version": %d}`, version.Load())
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes := make(chan struct{}, 10)
+	done := make(chan error)
+	go func() {
+		done <- HTTPSource{URL: srv.URL, Interval: 10 * time.Millisecond}.Watch(ctx, func() { changes <- struct{}{} })
+	}()
+
+	waitChange := func() {
+		t.Helper()
+
+		select {
+		case <-changes:
+		case <-time.After(5 * time.Second):
+			t.Fatal("onChange is not called")
+		}
+	}
+
+	// the first document is reported as a change
+	waitChange()
+
+	// not modified documents are not reported
+	deadline := time.Now().Add(5 * time.Second)
+	for notModified.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if notModified.Load() < 3 {
+		t.Fatalf("got %d not modified responses, want at least 3", notModified.Load())
+	}
+	if len(changes) != 0 {
+		t.Fatal("onChange is called for the not modified document")
+	}
+
+	version.Store(2)
+	waitChange()
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Watch() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Watch() is not stopped by the context")
+	}
+
+	if len(changes) != 0 {
+		t.Errorf("got %d extra changes", len(changes))
+	}
+}
@@ -0,0 +1,400 @@
+package configloader
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Source provides a layer of config values as a tree of maps, slices and scalars
+// keyed by field names. Sources are merged in order, the later ones take precedence.
+type Source interface {
+	Name() string
+	Read(ctx context.Context, dest any) (map[string]any, error)
+}
+
+// WatchableSource is a Source which can notify about its changes.
+// Watch blocks until ctx is done and calls onChange when the values may have changed.
+type WatchableSource interface {
+	Source
+	Watch(ctx context.Context, onChange func()) error
+}
+
+// layerSource is implemented by sources which know positions of their values
+type layerSource interface {
+	readLayer(ctx context.Context, dest any) (layer, error)
+}
+
+// LoadSources loads config from the sources, later sources override earlier ones,
+// then defaults, secret references and validation are applied as in Load
+func LoadSources(ctx context.Context, dest any, sources []Source, opts ...Option) error {
+	layers, err := readSources(ctx, dest, sources)
+	if err != nil {
+		return err
+	}
+
+	return load(layers, dest, newOptions(opts))
+}
+
+func readSources(ctx context.Context, dest any, sources []Source) ([]layer, error) {
+	layers := make([]layer, 0, len(sources))
+
+	for _, s := range sources {
+		l, err := readSource(ctx, dest, s)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", s.Name(), err)
+		}
+
+		layers = append(layers, l)
+	}
+
+	return layers, nil
+}
+
+func readSource(ctx context.Context, dest any, s Source) (layer, error) {
+	if ls, ok := s.(layerSource); ok {
+		return ls.readLayer(ctx, dest)
+	}
+
+	tree, err := s.Read(ctx, dest)
+	if err != nil {
+		return layer{}, err
+	}
+
+	return layer{name: s.Name(), tree: normalizeTree(tree)}, nil
+}
+
+// FileSource reads a config file or a config directory, see LoadDir
+type FileSource struct {
+	Path     string
+	Optional bool // missing file is an empty source
+}
+
+func (s FileSource) Name() string { return fileSourcePrefix + s.Path }
+
+func (s FileSource) Read(ctx context.Context, dest any) (map[string]any, error) {
+	l, err := s.readLayer(ctx, dest)
+	if err != nil {
+		return nil, err
+	}
+
+	m, _ := l.tree.(map[string]any)
+
+	return m, nil
+}
+
+func (s FileSource) readLayer(_ context.Context, _ any) (layer, error) {
+	info, err := os.Stat(s.Path)
+	if errors.Is(err, fs.ErrNotExist) && s.Optional {
+		return layer{name: s.Name()}, nil
+	}
+	if err != nil {
+		return layer{}, err
+	}
+
+	if !info.IsDir() {
+		return loadFileLayer(s.Path)
+	}
+
+	paths, err := fragmentPaths(s.Path)
+	if err != nil {
+		return layer{}, err
+	}
+
+	layers, err := loadFileLayers(paths)
+	if err != nil {
+		return layer{}, err
+	}
+
+	tree, err := mergeLayers(layers, nil)
+	if err != nil {
+		return layer{}, err
+	}
+
+	// positions of the fragments are lost after merging
+	return layer{name: s.Name(), tree: tree, document: true}, nil
+}
+
+// EnvSource reads the fields from environment variables like WithEnvPrefix does
+type EnvSource struct {
+	Prefix string
+}
+
+func (s EnvSource) Name() string { return "env:" + s.Prefix }
+
+func (s EnvSource) Read(_ context.Context, dest any) (map[string]any, error) {
+	v, trace := newOfType(dest), Trace{}
+
+	err := applyEnv(v.Interface(), s.Prefix, os.LookupEnv, trace)
+	if err != nil {
+		return nil, err
+	}
+
+	return tracedTree(v, trace)
+}
+
+// FlagsSource reads the fields from command-line flags like WithArgs does, the --config flag is ignored
+type FlagsSource struct {
+	Args []string
+}
+
+func (s FlagsSource) Name() string { return "flags" }
+
+func (s FlagsSource) Read(_ context.Context, dest any) (map[string]any, error) {
+	parsed, err := parseFlags(dest, s.Args, os.Stderr)
+	if err != nil {
+		return nil, err
+	}
+
+	v, trace := newOfType(dest), Trace{}
+
+	err = applyFlags(v.Interface(), parsed, trace)
+	if err != nil {
+		return nil, err
+	}
+
+	return tracedTree(v, trace)
+}
+
+// MapSource provides values from memory, keys may be dotted paths like "http.addr"
+type MapSource struct {
+	Title  string
+	Values map[string]any
+}
+
+func (s MapSource) Name() string {
+	if s.Title != "" {
+		return "map:" + s.Title
+	}
+
+	return "map"
+}
+
+func (s MapSource) Read(_ context.Context, _ any) (map[string]any, error) {
+	return expandTree(s.Values, "."), nil
+}
+
+// newOfType returns a pointer to the new zero value of the dest type
+func newOfType(dest any) reflect.Value {
+	t := reflect.TypeOf(dest)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	return reflect.New(t)
+}
+
+// tracedTree builds the tree of the fields of v which paths are in the trace
+func tracedTree(v reflect.Value, trace Trace) (map[string]any, error) {
+	tree := map[string]any{}
+
+	for _, f := range collectFields(v.Type()) {
+		if _, ok := trace[f.path]; !ok {
+			continue
+		}
+
+		value, err := treeValue(fieldByIndex(v.Elem(), f.index))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.path, err)
+		}
+
+		setTreePath(tree, f.path, value)
+	}
+
+	return tree, nil
+}
+
+// treeValue converts the field value into the json compatible value
+// which is decoded back into the same field value. Values are converted by their kinds,
+// so that the secrets inside slices, maps and structs are kept as they are.
+func treeValue(v reflect.Value) (any, error) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, nil
+		}
+
+		v = v.Elem()
+	}
+
+	t := v.Type()
+	switch {
+	case t == secondsType:
+		return int64(v.Int()) / 1e9, nil
+	case reflect.PointerTo(t).Implements(jsonUnmarshalerType) && t.Implements(jsonMarshalerType):
+		return marshaledValue(v.Interface().(json.Marshaler).MarshalJSON())
+	case reflect.PointerTo(t).Implements(textUnmarshalerType) && t.Implements(textMarshalerType):
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+
+		return string(text), err
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint(), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil, nil
+		}
+
+		s := make([]any, v.Len())
+		for i := range s {
+			item, err := treeValue(v.Index(i))
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+
+			s[i] = item
+		}
+
+		return s, nil
+	case reflect.Map:
+		if v.IsNil() {
+			return nil, nil
+		}
+
+		m := make(map[string]any, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			key := fmt.Sprint(iter.Key().Interface())
+
+			item, err := treeValue(iter.Value())
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+
+			m[key] = item
+		}
+
+		return m, nil
+	case reflect.Struct:
+		m := map[string]any{}
+
+		err := structTreeValue(v, m)
+		if err != nil {
+			return nil, err
+		}
+
+		return m, nil
+	default:
+		return nil, fmt.Errorf("unsupported type %s", t)
+	}
+}
+
+// structTreeValue adds the fields of the struct to m by their json names
+func structTreeValue(v reflect.Value, m map[string]any) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if strings.HasPrefix(field.Tag.Get("json"), "-") {
+			continue
+		}
+
+		fv := v.Field(i)
+		jsonName, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+
+		if field.Anonymous && jsonName == "" {
+			for fv.Kind() == reflect.Pointer && !fv.IsNil() {
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				err := structTreeValue(fv, m)
+				if err != nil {
+					return err
+				}
+
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		name := fieldName(field)
+
+		item, err := treeValue(fv)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+
+		m[name] = item
+	}
+
+	return nil
+}
+
+// marshaledValue decodes the json of the custom marshaler into the tree
+func marshaledValue(data []byte, err error) (any, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	tree := any(nil)
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	err = dec.Decode(&tree)
+	if err != nil {
+		return nil, err
+	}
+
+	return tree, nil
+}
+
+// setTreePath sets the value by the dotted path creating nested maps
+func setTreePath(tree map[string]any, path string, value any) {
+	keys := strings.Split(path, ".")
+
+	for _, k := range keys[:len(keys)-1] {
+		next, ok := tree[k].(map[string]any)
+		if !ok {
+			next = map[string]any{}
+			tree[k] = next
+		}
+
+		tree = next
+	}
+
+	tree[keys[len(keys)-1]] = value
+}
+
+// expandTree converts flat keys separated by sep into nested maps,
+// keys are processed in lexical order so that conflicts are resolved deterministically
+func expandTree(values map[string]any, sep string) map[string]any {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	tree := any(map[string]any{})
+	for _, k := range keys {
+		value := normalizeTree(values[k])
+		if m, ok := value.(map[string]any); ok {
+			value = expandTree(m, sep)
+		}
+
+		single := map[string]any{}
+		setTreePath(single, strings.Join(strings.Split(strings.Trim(k, sep), sep), "."), value)
+
+		tree = mergeTree(tree, single, "", "", nil)
+	}
+
+	m, _ := tree.(map[string]any)
+
+	return m
+}
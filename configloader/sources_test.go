@@ -0,0 +1,42 @@
+package configloader
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+type sourcesConfig struct {
+	Token   Secret
+	Tokens  []Secret
+	Ports   []int
+	Timeout time.Duration
+	Started time.Time
+}
+
+func TestLoadEnvSource(t *testing.T) {
+	t.Setenv("ZZ_TOKEN", "token")
+	t.Setenv("ZZ_TOKENS", "a,b")
+	t.Setenv("ZZ_PORTS", "80,443")
+	t.Setenv("ZZ_TIMEOUT", "5s")
+	t.Setenv("ZZ_STARTED", "2024-01-01T10:00:00Z")
+
+	cfg := sourcesConfig{}
+
+	err := LoadSources(context.Background(), &cfg, []Source{EnvSource{Prefix: "ZZ"}})
+	if err != nil {
+		t.Fatalf("LoadSources() error = %v", err)
+	}
+
+	tokens := make([]string, len(cfg.Tokens))
+	for i, s := range cfg.Tokens {
+		tokens[i] = s.Value()
+	}
+
+	got := fmt.Sprintf("%s %v %v %v %s", cfg.Token.Value(), tokens, cfg.Ports, cfg.Timeout, cfg.Started.Format(time.RFC3339))
+	want := "token [a b] [80 443] 5s 2024-01-01T10:00:00Z"
+	if got != want {
+		t.Errorf("LoadSources() = %s, want %s", got, want)
+	}
+}
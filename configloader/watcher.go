@@ -0,0 +1,344 @@
+package configloader
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/timmbarton/layout/log"
+)
+
+const DefaultWatchInterval = 5 * time.Second
+
+// Watcher reloads the config when its sources change and publishes the new value
+// to subscribers. Invalid edits are logged and skipped, the last valid config stays
+// active. Watcher is a lifecycle component.
+type Watcher[T any] struct {
+	name     string
+	interval time.Duration
+	opts     *options
+	read     func(ctx context.Context) ([]layer, error)
+	sources  []Source
+	l        *log.WrappedLogger
+
+	mu          sync.RWMutex
+	current     T
+	trace       Trace
+	fingerprint [sha256.Size]byte
+	lastErr     string
+
+	subsMu sync.Mutex
+	subs   map[int]func(prev, next T)
+	nextId int
+
+	reloadCh chan struct{}
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// NewWatcher watches the config file with its overlays, or the config directory, see LoadLayered.
+// It loads the config for the first time and returns an error if it is invalid,
+// zero interval means DefaultWatchInterval.
+func NewWatcher[T any](filePath string, interval time.Duration, opts ...Option) (*Watcher[T], error) {
+	o := newOptions(opts)
+
+	return newWatcher[T](
+		filePath,
+		interval,
+		o,
+		func(_ context.Context) ([]layer, error) { return loadLayers(filePath, o.environment) },
+		nil,
+	)
+}
+
+// NewSourcesWatcher watches the sources, see LoadSources. Sources implementing
+// WatchableSource trigger reloading immediately, the others are polled with the interval.
+func NewSourcesWatcher[T any](interval time.Duration, sources []Source, opts ...Option) (*Watcher[T], error) {
+	names := make([]string, len(sources))
+	for i, s := range sources {
+		names[i] = s.Name()
+	}
+
+	return newWatcher[T](
+		strings.Join(names, ", "),
+		interval,
+		newOptions(opts),
+		func(ctx context.Context) ([]layer, error) { return readSources(ctx, new(T), sources) },
+		sources,
+	)
+}
+
+func newWatcher[T any](
+	name string,
+	interval time.Duration,
+	o *options,
+	read func(ctx context.Context) ([]layer, error),
+	sources []Source,
+) (*Watcher[T], error) {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+
+	w := &Watcher[T]{
+		name:     name,
+		interval: interval,
+		opts:     o,
+		read:     read,
+		sources:  sources,
+		l:        log.Named("Config Watcher"),
+		subs:     map[int]func(prev, next T){},
+		reloadCh: make(chan struct{}, 1),
+	}
+
+	layers, err := w.read(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	w.fingerprint, err = getFingerprint(layers)
+	if err != nil {
+		return nil, err
+	}
+
+	// the first load fills the trace and the merge order requested by the options
+	err = load(layers, &w.current, w.opts)
+	if err != nil {
+		return nil, err
+	}
+	w.trace = w.opts.getTrace()
+
+	return w, nil
+}
+
+// Get returns the last valid config
+func (w *Watcher[T]) Get() T {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	return w.current
+}
+
+// Trace returns the sources of the values of the last valid config, e.g. to mask
+// its decrypted values with Dump. The trace of WithTrace is filled by the first load only.
+func (w *Watcher[T]) Trace() Trace {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	return w.trace
+}
+
+// Subscribe registers fn to be called with the previous and the new config on every change,
+// the returned function unsubscribes it
+func (w *Watcher[T]) Subscribe(fn func(prev, next T)) (unsubscribe func()) {
+	w.subsMu.Lock()
+	defer w.subsMu.Unlock()
+
+	id := w.nextId
+	w.nextId++
+	w.subs[id] = fn
+
+	return func() {
+		w.subsMu.Lock()
+		defer w.subsMu.Unlock()
+
+		delete(w.subs, id)
+	}
+}
+
+// Reload reads the sources and publishes the new config if they have changed
+func (w *Watcher[T]) Reload(ctx context.Context) error {
+	layers, err := w.read(ctx)
+	if err != nil {
+		w.reject(ctx, err)
+		return err
+	}
+
+	fingerprint, err := getFingerprint(layers)
+	if err != nil {
+		return err
+	}
+
+	w.mu.RLock()
+	unchanged := fingerprint == w.fingerprint
+	w.mu.RUnlock()
+
+	if unchanged {
+		return nil
+	}
+
+	// every load gets its own trace, so that concurrent reloads don't share it
+	// and the keys removed from the sources don't keep their former sources
+	next, trace := *new(T), Trace{}
+	o := *w.opts
+	o.trace, o.order = &trace, nil
+
+	err = load(layers, &next, &o)
+	if err != nil {
+		w.reject(ctx, err)
+		return err
+	}
+
+	w.mu.Lock()
+	prev := w.current
+	w.current, w.trace = next, trace
+	w.fingerprint = fingerprint
+	w.lastErr = ""
+	w.mu.Unlock()
+
+	w.l.Info(ctx, "config is reloaded", zap.String("sources", w.name))
+
+	w.subsMu.Lock()
+	subs := make([]func(prev, next T), 0, len(w.subs))
+	for _, fn := range w.subs {
+		subs = append(subs, fn)
+	}
+	w.subsMu.Unlock()
+
+	for _, fn := range subs {
+		fn(prev, next)
+	}
+
+	return nil
+}
+
+// reject logs the error once until the config changes
+func (w *Watcher[T]) reject(ctx context.Context, err error) {
+	w.mu.Lock()
+	repeated := w.lastErr == err.Error()
+	w.lastErr = err.Error()
+	w.mu.Unlock()
+
+	if !repeated {
+		w.l.Error(ctx, "config change is rejected, keeping the last valid config", zap.String("sources", w.name), zap.Error(err))
+	}
+}
+
+// getFingerprint hashes the names and the values of the layers together with the files
+// of their file:// references, so that rotated secret files are reloaded as well.
+// References set by env variables and default tags are not watched.
+func getFingerprint(layers []layer) ([sha256.Size]byte, error) {
+	h := sha256.New()
+
+	for _, l := range layers {
+		data, err := json.Marshal(l.tree)
+		if err != nil {
+			return [sha256.Size]byte{}, err
+		}
+
+		_, _ = fmt.Fprintf(h, "%s:%d:", l.name, len(data))
+		_, _ = h.Write(data)
+
+		hashFileRefs(h, l.tree)
+	}
+
+	return [sha256.Size]byte(h.Sum(nil)), nil
+}
+
+// hashFileRefs writes the contents of the files referenced by the tree values to h,
+// a file which can't be read is hashed by the error
+func hashFileRefs(h io.Writer, tree any) {
+	switch v := tree.(type) {
+	case map[string]any:
+		for _, k := range slices.Sorted(maps.Keys(v)) {
+			hashFileRefs(h, v[k])
+		}
+	case []any:
+		for _, item := range v {
+			hashFileRefs(h, item)
+		}
+	case string:
+		path, ok := strings.CutPrefix(v, fileRefPrefix)
+		if !ok {
+			return
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			data = []byte(err.Error())
+		}
+
+		_, _ = fmt.Fprintf(h, "%s:%d:", v, len(data))
+		_, _ = h.Write(data)
+	}
+}
+
+func (w *Watcher[T]) Start(_ context.Context) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+
+	for _, s := range w.sources {
+		ws, ok := s.(WatchableSource)
+		if !ok {
+			continue
+		}
+
+		w.wg.Add(1)
+		go func() {
+			defer w.wg.Done()
+
+			err := ws.Watch(ctx, w.triggerReload)
+			if err != nil {
+				w.l.Error(ctx, "can't watch config source", zap.String("source", ws.Name()), zap.Error(err))
+			}
+		}()
+	}
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-w.reloadCh:
+			}
+
+			_ = w.Reload(ctx)
+		}
+	}()
+
+	return nil
+}
+func (w *Watcher[T]) Stop(ctx context.Context) error {
+	if w.cancel == nil {
+		return nil
+	}
+
+	w.cancel()
+
+	doneCh := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(doneCh)
+	}()
+
+	select {
+	case <-doneCh:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+func (w *Watcher[T]) GetName() string { return fmt.Sprintf("Config Watcher of %s", w.name) }
+
+func (w *Watcher[T]) triggerReload() {
+	select {
+	case w.reloadCh <- struct{}{}:
+	default:
+	}
+}
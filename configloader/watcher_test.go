@@ -0,0 +1,73 @@
+package configloader
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type watchedConfig struct {
+	A        string
+	B        string
+	Password Secret
+}
+
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+
+	err := os.WriteFile(path, []byte(data), 0o600)
+	if err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+}
+
+func TestWatcherReload(t *testing.T) {
+	dir := t.TempDir()
+	configPath, secretPath := filepath.Join(dir, "config.json"), filepath.Join(dir, "password")
+
+	writeFile(t, secretPath, "first")
+	writeFile(t, configPath, `{"A": "a", "B": "b", "Password": "file://`+secretPath+`"}`)
+
+	w, err := NewWatcher[watchedConfig](configPath, 0, WithEnvironment("none"))
+	if err != nil {
+		t.Fatalf("NewWatcher() error = %v", err)
+	}
+	if _, ok := w.Trace()["b"]; !ok {
+		t.Fatalf("Trace() = %v, want the source of b", w.Trace())
+	}
+
+	changes := 0
+	w.Subscribe(func(_, _ watchedConfig) { changes++ })
+
+	// the removed key is dropped from the trace
+	writeFile(t, configPath, `{"A": "a", "Password": "file://`+secretPath+`"}`)
+
+	err = w.Reload(context.Background())
+	if err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if source, ok := w.Trace()["b"]; ok {
+		t.Errorf("Trace() has b <- %s after b is removed", source)
+	}
+
+	// the rotated secret file is reloaded although the config file is the same
+	writeFile(t, secretPath, "second")
+
+	err = w.Reload(context.Background())
+	if err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if got := w.Get().Password.Value(); got != "second" {
+		t.Errorf("Password = %q, want the rotated value", got)
+	}
+
+	// nothing is published if nothing is changed
+	err = w.Reload(context.Background())
+	if err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if changes != 2 {
+		t.Errorf("got %d changes, want 2", changes)
+	}
+}
@@ -7,3 +7,8 @@ type Lifecycle interface {
 	Stop(ctx context.Context) error
 	GetName() string
 }
+
+// Addressable is implemented by components which listen on or connect to an address
+type Addressable interface {
+	GetAddr() string
+}
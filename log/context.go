@@ -0,0 +1,180 @@
+package log
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// Names of the built-in extractors
+const (
+	ExtractorOTel      = "otel"
+	ExtractorRequestId = "request_id"
+	ExtractorUserId    = "user_id"
+	ExtractorTenant    = "tenant"
+)
+
+// Extractor returns log fields built from the context values, nil if there are none
+type Extractor func(ctx context.Context) []zap.Field
+
+type namedExtractor struct {
+	name string
+	fn   Extractor
+}
+
+var (
+	extractorsMu sync.Mutex
+	extractors   atomic.Pointer[[]namedExtractor]
+)
+
+func init() {
+	RegisterExtractor(ExtractorOTel, extractOTel)
+	RegisterExtractor(ExtractorRequestId, ValueExtractor("request_id", requestIdKey{}))
+	RegisterExtractor(ExtractorUserId, ValueExtractor("user_id", userIdKey{}))
+	RegisterExtractor(ExtractorTenant, ValueExtractor("tenant", tenantKey{}))
+}
+
+// RegisterExtractor adds the extractor which fields are appended to every log entry with a context,
+// the extractor with the same name is replaced in place
+func RegisterExtractor(name string, fn Extractor) {
+	extractorsMu.Lock()
+	defer extractorsMu.Unlock()
+
+	list := []namedExtractor(nil)
+	if current := extractors.Load(); current != nil {
+		list = slices.Clone(*current)
+	}
+
+	i := slices.IndexFunc(list, func(e namedExtractor) bool { return e.name == name })
+	if i >= 0 {
+		list[i].fn = fn
+	} else {
+		list = append(list, namedExtractor{name: name, fn: fn})
+	}
+
+	extractors.Store(&list)
+}
+
+// UnregisterExtractor removes the extractor, built-in ones included
+func UnregisterExtractor(name string) {
+	extractorsMu.Lock()
+	defer extractorsMu.Unlock()
+
+	current := extractors.Load()
+	if current == nil {
+		return
+	}
+
+	list := slices.DeleteFunc(slices.Clone(*current), func(e namedExtractor) bool { return e.name == name })
+	extractors.Store(&list)
+}
+
+// ContextFields returns the fields of all registered extractors
+func ContextFields(ctx context.Context) []zap.Field {
+	if ctx == nil {
+		return nil
+	}
+
+	current := extractors.Load()
+	if current == nil {
+		return nil
+	}
+
+	fields := []zap.Field(nil)
+	for _, e := range *current {
+		fields = append(fields, e.fn(ctx)...)
+	}
+
+	return fields
+}
+
+// ValueExtractor returns the extractor of the context value stored by ctxKey as the field with the key,
+// the value is skipped if it is nil or an empty string
+func ValueExtractor(key string, ctxKey any) Extractor {
+	return func(ctx context.Context) []zap.Field {
+		v := ctx.Value(ctxKey)
+		if v == nil || v == "" {
+			return nil
+		}
+
+		return []zap.Field{zap.Any(key, v)}
+	}
+}
+
+func extractOTel(ctx context.Context) []zap.Field {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+
+	return []zap.Field{
+		zap.String("trace_id", sc.TraceID().String()),
+		zap.String("span_id", sc.SpanID().String()),
+	}
+}
+
+type (
+	requestIdKey struct{}
+	userIdKey    struct{}
+	tenantKey    struct{}
+)
+
+// NewRequestId returns a random request id for requests which don't have one
+func NewRequestId() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+// maxRequestIdLength is the limit of request ids received from clients
+const maxRequestIdLength = 128
+
+// IsValidRequestId reports whether the request id received from a client may be logged
+// and returned in responses: up to 128 letters, digits and '-', '_', '.', ':' characters
+func IsValidRequestId(id string) bool {
+	if id == "" || len(id) > maxRequestIdLength {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		isAlnum := c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+		if !isAlnum && c != '-' && c != '_' && c != '.' && c != ':' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// WithRequestId stores the request id which is logged by the request_id extractor
+func WithRequestId(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIdKey{}, id)
+}
+
+// WithUserId stores the user id which is logged by the user_id extractor
+func WithUserId(ctx context.Context, id any) context.Context {
+	return context.WithValue(ctx, userIdKey{}, id)
+}
+
+// WithTenant stores the tenant which is logged by the tenant extractor
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+func RequestId(ctx context.Context) string {
+	id, _ := ctx.Value(requestIdKey{}).(string)
+	return id
+}
+func UserId(ctx context.Context) any { return ctx.Value(userIdKey{}) }
+func Tenant(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
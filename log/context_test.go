@@ -0,0 +1,28 @@
+package log
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIsValidRequestId(t *testing.T) {
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{id: NewRequestId(), want: true},
+		{id: "req-1_2.3:4", want: true},
+		{id: strings.Repeat("a", 128), want: true},
+		{id: strings.Repeat("a", 129), want: false},
+		{id: "", want: false},
+		{id: "id with spaces", want: false},
+		{id: "id\nforged entry", want: false},
+		{id: "id\"}", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := IsValidRequestId(tt.id); got != tt.want {
+			t.Errorf("IsValidRequestId(%q) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
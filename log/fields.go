@@ -0,0 +1,72 @@
+package log
+
+import (
+	"context"
+	"slices"
+
+	"go.uber.org/zap"
+)
+
+type fieldsKey struct{}
+
+// ctxFields are the fields added by one WithContext call, parent holds the earlier ones
+type ctxFields struct {
+	parent *ctxFields
+	fields []zap.Field
+}
+
+// WithContext returns the context carrying the fields in addition to the fields of ctx,
+// they are added to every entry logged with the returned context
+func WithContext(ctx context.Context, fields ...zap.Field) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if len(fields) == 0 {
+		return ctx
+	}
+
+	parent, _ := ctx.Value(fieldsKey{}).(*ctxFields)
+
+	return context.WithValue(ctx, fieldsKey{}, &ctxFields{parent: parent, fields: fields})
+}
+
+// FromContext returns the global logger with the fields of the context
+func FromContext(ctx context.Context) *WrappedLogger {
+	if ctx == nil {
+		return NewWrappedLogger(zap.L())
+	}
+
+	bound, _ := ctx.Value(fieldsKey{}).(*ctxFields)
+
+	w := NewWrappedLogger(zap.L().With(bound.collect(nil)...))
+	w.bound = bound
+
+	return w
+}
+
+// collect returns the fields added after the bound ones in the order they were added
+func (f *ctxFields) collect(bound *ctxFields) []zap.Field {
+	chain := []*ctxFields(nil)
+	for ; f != nil && f != bound; f = f.parent {
+		chain = append(chain, f)
+	}
+
+	fields := []zap.Field(nil)
+	for _, c := range slices.Backward(chain) {
+		fields = append(fields, c.fields...)
+	}
+
+	return fields
+}
+
+// contextFields returns the fields of the context which are not bound to the logger yet
+// followed by the fields of the extractors
+func contextFields(ctx context.Context, bound *ctxFields) []zap.Field {
+	if ctx == nil {
+		return nil
+	}
+
+	f, _ := ctx.Value(fieldsKey{}).(*ctxFields)
+
+	return append(f.collect(bound), ContextFields(ctx)...)
+}
@@ -0,0 +1,214 @@
+package log
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// RootLogger is the name of the level applied to loggers without their own level
+const RootLogger = ""
+
+var levels = newLevelRegistry(zapcore.InfoLevel)
+
+// levelRegistry keeps the levels of named loggers, the level of a logger is the level
+// of its longest configured name prefix: "a.b" inherits "a" which inherits the root level
+type levelRegistry struct {
+	mu     sync.RWMutex
+	levels map[string]zapcore.Level
+	min    zap.AtomicLevel // the lowest configured level for quick Enabled checks
+	timers map[string]*levelTimer
+	root   zapcore.Level // the root level configured by Setup, restored by ResetLevel
+}
+
+// levelTimer reverts the level to the one before the temporary change
+type levelTimer struct {
+	timer   *time.Timer
+	prev    zapcore.Level
+	hadPrev bool
+}
+
+func newLevelRegistry(root zapcore.Level) *levelRegistry {
+	return &levelRegistry{
+		levels: map[string]zapcore.Level{RootLogger: root},
+		min:    zap.NewAtomicLevelAt(root),
+		timers: map[string]*levelTimer{},
+		root:   root,
+	}
+}
+
+// SetLevel sets the level of the named logger and its children, RootLogger sets the default level.
+// Positive revertAfter restores the previous level after the timeout.
+func SetLevel(name string, lvl zapcore.Level, revertAfter time.Duration) {
+	levels.set(name, lvl, revertAfter)
+}
+
+// ResetLevel removes the level of the named logger so that it inherits the parent level,
+// the root level is reset to the one configured by Setup, info by default
+func ResetLevel(name string) {
+	levels.reset(name)
+}
+
+// GetLevel returns the effective level of the named logger
+func GetLevel(name string) zapcore.Level {
+	levels.mu.RLock()
+	defer levels.mu.RUnlock()
+
+	return levels.levelOf(name)
+}
+
+// Levels returns the configured levels by logger names
+func Levels() map[string]zapcore.Level {
+	levels.mu.RLock()
+	defer levels.mu.RUnlock()
+
+	return maps.Clone(levels.levels)
+}
+
+// LevelEnabler enables the levels which are enabled for any logger,
+// it is used by cores wrapped with NewLevelCore
+func LevelEnabler() zapcore.LevelEnabler { return levels.min }
+
+// NewLevelCore wraps the core to filter entries by the levels of their loggers, see SetLevel
+func NewLevelCore(core zapcore.Core) zapcore.Core {
+	return &levelCore{Core: core}
+}
+
+func (r *levelRegistry) set(name string, lvl zapcore.Level, revertAfter time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	prev, hadPrev := r.levels[name]
+
+	t, pending := r.timers[name]
+	if pending {
+		// keep the level from before the first temporary change
+		t.timer.Stop()
+		prev, hadPrev = t.prev, t.hadPrev
+		delete(r.timers, name)
+	}
+
+	r.levels[name] = lvl
+	r.updateMin()
+
+	if revertAfter <= 0 {
+		return
+	}
+
+	t = &levelTimer{prev: prev, hadPrev: hadPrev}
+	t.timer = time.AfterFunc(revertAfter, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		if r.timers[name] != t {
+			return
+		}
+		delete(r.timers, name)
+
+		if t.hadPrev {
+			r.levels[name] = t.prev
+		} else {
+			delete(r.levels, name)
+		}
+		r.updateMin()
+	})
+	r.timers[name] = t
+}
+
+// setRoot sets the configured root level, its temporary changes are dropped
+func (r *levelRegistry) setRoot(lvl zapcore.Level) {
+	r.mu.Lock()
+	r.root = lvl
+	r.mu.Unlock()
+
+	r.reset(RootLogger)
+}
+
+func (r *levelRegistry) reset(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if t, ok := r.timers[name]; ok {
+		t.timer.Stop()
+		delete(r.timers, name)
+	}
+
+	if name == RootLogger {
+		r.levels[name] = r.root
+	} else {
+		delete(r.levels, name)
+	}
+	r.updateMin()
+}
+
+func (r *levelRegistry) updateMin() {
+	lowest := zapcore.InvalidLevel
+	for _, lvl := range r.levels {
+		if lowest == zapcore.InvalidLevel || lvl < lowest {
+			lowest = lvl
+		}
+	}
+
+	r.min.SetLevel(lowest)
+}
+
+func (r *levelRegistry) levelOf(name string) zapcore.Level {
+	for {
+		if lvl, ok := r.levels[name]; ok {
+			return lvl
+		}
+
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			return r.levels[RootLogger]
+		}
+
+		name = name[:i]
+	}
+}
+
+func (r *levelRegistry) enabled(name string, lvl zapcore.Level) bool {
+	if !r.min.Enabled(lvl) {
+		return false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.levelOf(name).Enabled(lvl)
+}
+
+type levelCore struct {
+	zapcore.Core
+}
+
+func (c *levelCore) Enabled(lvl zapcore.Level) bool {
+	return levels.min.Enabled(lvl) && c.Core.Enabled(lvl)
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields)}
+}
+
+func (c *levelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !levels.enabled(ent.LoggerName, ent.Level) {
+		return ce
+	}
+
+	return c.Core.Check(ent, ce)
+}
+
+// parseLevel parses level names like zap does, e.g. "debug" or "WARN"
+func parseLevel(s string) (zapcore.Level, error) {
+	lvl, err := zapcore.ParseLevel(s)
+	if err != nil {
+		return lvl, fmt.Errorf("unknown level %q", s)
+	}
+
+	return lvl, nil
+}
@@ -0,0 +1,98 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// LevelsHandler serves the levels of named loggers:
+//
+//	GET                                       returns the configured levels
+//	PUT ?name=<logger>&level=debug&revert=10m sets the level, name and revert are optional
+//	DELETE ?name=<logger>                     resets the level to the inherited one
+func LevelsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		name := query.Get("name")
+
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost:
+			lvl, err := parseLevel(query.Get("level"))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			revertAfter := time.Duration(0)
+			if s := query.Get("revert"); s != "" {
+				revertAfter, err = time.ParseDuration(s)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+			}
+
+			SetLevel(name, lvl, revertAfter)
+			Named("Log Levels").Info(
+				r.Context(),
+				"log level is changed",
+				zap.String("logger", name),
+				zap.Stringer("level", lvl),
+				zap.Duration("revert_after", revertAfter),
+			)
+		case http.MethodDelete:
+			ResetLevel(name)
+		default:
+			w.Header().Set("Allow", "GET, PUT, POST, DELETE")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		result := map[string]string{}
+		for n, lvl := range Levels() {
+			result[n] = lvl.String()
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(result)
+	})
+}
+
+// ToggleDebugOnSignal switches the root level to debug on the signal (e.g. SIGUSR1)
+// and back on the next one or after revertAfter if it is positive. It stops when ctx is done.
+func ToggleDebugOnSignal(ctx context.Context, sig os.Signal, revertAfter time.Duration) {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, sig)
+
+	go func() {
+		defer signal.Stop(sigCh)
+
+		prev, toggled := zapcore.InfoLevel, false
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-sigCh:
+			}
+
+			// the level may be reverted by the timeout already
+			if toggled && GetLevel(RootLogger) == zapcore.DebugLevel {
+				SetLevel(RootLogger, prev, 0)
+				toggled = false
+			} else {
+				prev, toggled = GetLevel(RootLogger), true
+				SetLevel(RootLogger, zapcore.DebugLevel, revertAfter)
+			}
+
+			Named("Log Levels").Info(ctx, "root log level is toggled by signal", zap.Stringer("level", GetLevel(RootLogger)))
+		}
+	}()
+}
@@ -0,0 +1,92 @@
+package log
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLevelInheritance(t *testing.T) {
+	r := newLevelRegistry(zapcore.InfoLevel)
+	r.set("db", zapcore.DebugLevel, 0)
+	r.set("db.pool", zapcore.ErrorLevel, 0)
+
+	tests := []struct {
+		name string
+		want zapcore.Level
+	}{
+		{name: "", want: zapcore.InfoLevel},
+		{name: "http", want: zapcore.InfoLevel},
+		{name: "db", want: zapcore.DebugLevel},
+		{name: "db.queries", want: zapcore.DebugLevel},
+		{name: "db.pool", want: zapcore.ErrorLevel},
+		{name: "db.pool.conn", want: zapcore.ErrorLevel},
+		{name: "dbx", want: zapcore.InfoLevel},
+	}
+
+	for _, tt := range tests {
+		if got := r.levelOf(tt.name); got != tt.want {
+			t.Errorf("levelOf(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if !r.enabled("db.queries", zapcore.DebugLevel) || r.enabled("db.pool", zapcore.WarnLevel) {
+		t.Error("enabled() doesn't follow the levels of the loggers")
+	}
+
+	r.reset("db.pool")
+	if got := r.levelOf("db.pool"); got != zapcore.DebugLevel {
+		t.Errorf("levelOf() after reset = %v, want the parent level %v", got, zapcore.DebugLevel)
+	}
+
+	r.reset("db")
+	if got := r.min.Level(); got != zapcore.InfoLevel {
+		t.Errorf("min level after reset = %v, want %v", got, zapcore.InfoLevel)
+	}
+}
+
+func TestLevelRevert(t *testing.T) {
+	r := newLevelRegistry(zapcore.InfoLevel)
+	r.set("db", zapcore.WarnLevel, 0)
+
+	// the level from before the first temporary change is restored
+	r.set("db", zapcore.DebugLevel, time.Hour)
+	r.set("db", zapcore.ErrorLevel, 20*time.Millisecond)
+	r.set("http", zapcore.DebugLevel, 20*time.Millisecond)
+
+	if got := r.levelOf("db"); got != zapcore.ErrorLevel {
+		t.Fatalf("levelOf() = %v, want %v", got, zapcore.ErrorLevel)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		r.mu.RLock()
+		_, httpSet := r.levels["http"]
+		dbLevel := r.levelOf("db")
+		r.mu.RUnlock()
+
+		if !httpSet && dbLevel == zapcore.WarnLevel {
+			return
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Errorf("levels = %v, want db reverted to warn and http removed", r.levels)
+}
+
+func TestResetRootLevel(t *testing.T) {
+	err := Setup(Config{Level: "warn"})
+	if err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+	t.Cleanup(func() { _ = Setup(Config{}) })
+
+	SetLevel(RootLogger, zapcore.DebugLevel, 0)
+	ResetLevel(RootLogger)
+
+	if got := GetLevel(RootLogger); got != zapcore.WarnLevel {
+		t.Errorf("GetLevel() after ResetLevel = %v, want the configured %v", got, zapcore.WarnLevel)
+	}
+}
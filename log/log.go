@@ -8,6 +8,9 @@ import (
 	"go.uber.org/zap/zapcore"
 )
 
+// CtxKey was the key of the whole context in log entries.
+//
+// Deprecated: the context is not logged anymore, its values are logged by extractors, see RegisterExtractor.
 const CtxKey = "context"
 
 func Named(s string) *WrappedLogger {
@@ -24,58 +27,42 @@ func WithLazy(fields ...zap.Field) *WrappedLogger {
 }
 
 func Log(ctx context.Context, lvl zapcore.Level, msg string, fields ...zap.Field) {
-	if ctx != nil {
-		fields = append(fields, zap.Any(CtxKey, ctx))
-	}
+	fields = append(fields, contextFields(ctx, nil)...)
 
 	zap.L().Log(lvl, msg, fields...)
 }
 func Debug(ctx context.Context, msg string, fields ...zap.Field) {
-	if ctx != nil {
-		fields = append(fields, zap.Any(CtxKey, ctx))
-	}
+	fields = append(fields, contextFields(ctx, nil)...)
 
 	zap.L().Debug(msg, fields...)
 }
 func Info(ctx context.Context, msg string, fields ...zap.Field) {
-	if ctx != nil {
-		fields = append(fields, zap.Any(CtxKey, ctx))
-	}
+	fields = append(fields, contextFields(ctx, nil)...)
 
 	zap.L().Info(msg, fields...)
 }
 func Warn(ctx context.Context, msg string, fields ...zap.Field) {
-	if ctx != nil {
-		fields = append(fields, zap.Any(CtxKey, ctx))
-	}
+	fields = append(fields, contextFields(ctx, nil)...)
 
 	zap.L().Warn(msg, fields...)
 }
 func Error(ctx context.Context, msg string, fields ...zap.Field) {
-	if ctx != nil {
-		fields = append(fields, zap.Any(CtxKey, ctx))
-	}
+	fields = append(fields, contextFields(ctx, nil)...)
 
 	zap.L().Error(msg, fields...)
 }
 func DPanic(ctx context.Context, msg string, fields ...zap.Field) {
-	if ctx != nil {
-		fields = append(fields, zap.Any(CtxKey, ctx))
-	}
+	fields = append(fields, contextFields(ctx, nil)...)
 
 	zap.L().DPanic(msg, fields...)
 }
 func Panic(ctx context.Context, msg string, fields ...zap.Field) {
-	if ctx != nil {
-		fields = append(fields, zap.Any(CtxKey, ctx))
-	}
+	fields = append(fields, contextFields(ctx, nil)...)
 
 	zap.L().Panic(msg, fields...)
 }
 func Fatal(ctx context.Context, msg string, fields ...zap.Field) {
-	if ctx != nil {
-		fields = append(fields, zap.Any(CtxKey, ctx))
-	}
+	fields = append(fields, contextFields(ctx, nil)...)
 
 	zap.L().Fatal(msg, fields...)
 }
@@ -83,7 +70,12 @@ func Fatal(ctx context.Context, msg string, fields ...zap.Field) {
 func Sync() error        { return zap.L().Sync() }
 func Core() zapcore.Core { return zap.L().Core() }
 
+// Json logs v as the json string, sensitive values are masked, see SetRedaction
 func Json(key string, v any) zap.Field {
 	data, _ := json.Marshal(v)
+	if r := currentRedactor.Load(); r != nil {
+		data, _ = r.json(data)
+	}
+
 	return zap.String(key, string(data))
 }
@@ -0,0 +1,409 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/timmbarton/layout/secret"
+)
+
+// cardNumberRe matches candidates of card numbers which are checked by the Luhn algorithm
+var cardNumberRe = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+
+// minCardDigits is the least number of digits in a string matched by cardNumberRe
+const minCardDigits = 13
+
+// minCardNumber is the least integer of minCardDigits digits
+const minCardNumber = 1_000_000_000_000
+
+// RedactionConfig of the masking of sensitive values in log fields
+type RedactionConfig struct {
+	Disable  bool
+	Keys     []string // in addition to secret.SensitiveNames
+	Patterns []string // regular expressions of values to mask in addition to card numbers
+}
+
+type redactor struct {
+	keys     []string
+	patterns []*regexp.Regexp
+}
+
+var currentRedactor atomic.Pointer[redactor]
+
+func init() {
+	_ = SetRedaction(RedactionConfig{})
+}
+
+// SetRedaction replaces the redaction rules, Setup calls it with Config.Redaction.
+// Values of the keys having secret.SensitiveNames or Keys are masked,
+// keys are compared by secret.HasName.
+func SetRedaction(cfg RedactionConfig) error {
+	if cfg.Disable {
+		currentRedactor.Store(nil)
+		return nil
+	}
+
+	r := &redactor{patterns: []*regexp.Regexp{cardNumberRe}}
+	for _, k := range slices.Concat(secret.SensitiveNames, cfg.Keys) {
+		r.keys = append(r.keys, secret.NormalizeName(k))
+	}
+	for _, p := range cfg.Patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return err
+		}
+
+		r.patterns = append(r.patterns, re)
+	}
+
+	currentRedactor.Store(r)
+
+	return nil
+}
+
+// NewRedactCore wraps the core to mask sensitive values of fields before they are written
+func NewRedactCore(core zapcore.Core) zapcore.Core {
+	return &redactCore{Core: core}
+}
+
+type redactCore struct {
+	zapcore.Core
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(redactFields(fields))}
+}
+
+func (c *redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Core.Enabled(ent.Level) {
+		return ce
+	}
+
+	return ce.AddCore(ent, c)
+}
+
+func (c *redactCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(ent, redactFields(fields))
+}
+
+// redactFields returns the fields with masked values, the slice is copied only if a field is replaced
+func redactFields(fields []zapcore.Field) []zapcore.Field {
+	r := currentRedactor.Load()
+	if r == nil {
+		return fields
+	}
+
+	result, copied := fields, false
+	for i, f := range fields {
+		replaced, ok := r.field(f)
+		if !ok {
+			continue
+		}
+
+		if !copied {
+			result, copied = slices.Clone(fields), true
+		}
+		result[i] = replaced
+	}
+
+	return result
+}
+
+// field returns the replacement of the field and true if the field is replaced
+func (r *redactor) field(f zapcore.Field) (zapcore.Field, bool) {
+	if f.Type == zapcore.NamespaceType || f.Type == zapcore.SkipType {
+		return f, false
+	}
+
+	if f.Type != zapcore.InlineMarshalerType && r.isRedactedKey(f.Key) {
+		return zap.String(f.Key, secret.Redacted), true
+	}
+
+	switch f.Type {
+	case zapcore.Int64Type, zapcore.Int32Type, zapcore.Int16Type, zapcore.Int8Type,
+		zapcore.Uint64Type, zapcore.Uint32Type, zapcore.Uint16Type, zapcore.Uint8Type, zapcore.UintptrType:
+		// numbers shorter than card numbers are not formatted at all
+		if f.Integer > -minCardNumber && f.Integer < minCardNumber {
+			return f, false
+		}
+
+		s := strconv.FormatInt(f.Integer, 10)
+		if f.Type == zapcore.Uint64Type || f.Type == zapcore.UintptrType {
+			s = strconv.FormatUint(uint64(f.Integer), 10)
+		}
+
+		if _, ok := r.values(s); ok {
+			return zap.String(f.Key, secret.Redacted), true
+		}
+	case zapcore.StringType:
+		s, ok := r.string(f.String)
+		if ok {
+			return zap.String(f.Key, s), true
+		}
+	case zapcore.ByteStringType:
+		b, _ := f.Interface.([]byte)
+
+		s, ok := r.string(string(b))
+		if ok {
+			return zap.String(f.Key, s), true
+		}
+	case zapcore.ErrorType:
+		err, _ := f.Interface.(error)
+		if err == nil {
+			return f, false
+		}
+
+		s, ok := r.string(err.Error())
+		if ok {
+			return zap.String(f.Key, s), true
+		}
+	case zapcore.ObjectMarshalerType, zapcore.ArrayMarshalerType, zapcore.InlineMarshalerType:
+		// the value is encoded to maps and slices to be walked like a json document
+		enc := zapcore.NewMapObjectEncoder()
+		f.AddTo(enc)
+
+		if f.Type == zapcore.InlineMarshalerType {
+			masked, ok := r.tree(enc.Fields)
+			if ok {
+				return zap.Inline(maskedObject(masked.(map[string]any))), true
+			}
+
+			return f, false
+		}
+
+		masked, ok := r.tree(enc.Fields[f.Key])
+		if !ok {
+			return f, false
+		}
+		if m, isMap := masked.(map[string]any); isMap {
+			return zap.Object(f.Key, maskedObject(m)), true
+		}
+
+		return zap.Reflect(f.Key, masked), true
+	case zapcore.ReflectType:
+		data, err := marshalJSON(f.Interface)
+		if err != nil {
+			return f, false
+		}
+
+		// the masked value is passed as raw json, so that it is not marshaled twice
+		masked, ok := r.json(data)
+		if !ok {
+			return f, false
+		}
+
+		return zap.Reflect(f.Key, json.RawMessage(masked)), true
+	}
+
+	return f, false
+}
+
+// string masks the values matching the patterns, json documents are masked by keys as well
+func (r *redactor) string(s string) (string, bool) {
+	trimmed := strings.TrimSpace(s)
+	if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		data, ok := r.json([]byte(s))
+		if ok {
+			return string(data), true
+		}
+	}
+
+	return r.values(s)
+}
+
+// values masks the parts of the string matching the patterns
+func (r *redactor) values(s string) (string, bool) {
+	changed := false
+	for _, re := range r.patterns {
+		if re == cardNumberRe && !hasDigits(s, minCardDigits) {
+			continue
+		}
+
+		s = re.ReplaceAllStringFunc(s, func(match string) string {
+			if re == cardNumberRe && !isLuhnValid(match) {
+				return match
+			}
+
+			changed = true
+
+			return secret.Redacted
+		})
+	}
+
+	return s, changed
+}
+
+// json masks the values of the json document, it is returned as is if nothing is masked.
+// The document is parsed only if it mentions a redacted key or has a value matching the patterns.
+func (r *redactor) json(data []byte) ([]byte, bool) {
+	if !r.mentionsKey(string(data)) {
+		if _, ok := r.values(string(data)); !ok {
+			return data, false
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	tree := any(nil)
+	if dec.Decode(&tree) != nil {
+		return data, false
+	}
+
+	tree, changed := r.tree(tree)
+	if !changed {
+		return data, false
+	}
+
+	masked, err := marshalJSON(tree)
+	if err != nil {
+		return data, false
+	}
+
+	return masked, true
+}
+
+// tree masks the values of decoded json documents and of the maps and slices of zapcore.MapObjectEncoder
+func (r *redactor) tree(tree any) (any, bool) {
+	changed := false
+
+	switch v := tree.(type) {
+	case map[string]any:
+		for k, value := range v {
+			if r.isRedactedKey(k) {
+				if value != nil && value != "" {
+					v[k], changed = secret.Redacted, true
+				}
+
+				continue
+			}
+
+			masked, ok := r.tree(value)
+			v[k], changed = masked, changed || ok
+		}
+	case []any:
+		for i, value := range v {
+			masked, ok := r.tree(value)
+			v[i], changed = masked, changed || ok
+		}
+	case string:
+		return r.string(v)
+	case []byte:
+		return r.string(string(v))
+	case json.Number:
+		if _, ok := r.values(v.String()); ok {
+			return secret.Redacted, true
+		}
+	case int64, uint64:
+		if _, ok := r.values(fmt.Sprint(v)); ok {
+			return secret.Redacted, true
+		}
+	default:
+		// reflected values of objects, e.g. structs in slog groups
+		switch reflect.Indirect(reflect.ValueOf(v)).Kind() {
+		case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+			data, err := marshalJSON(v)
+			if err != nil {
+				return tree, false
+			}
+
+			masked, ok := r.json(data)
+			if ok {
+				return json.RawMessage(masked), true
+			}
+		}
+	}
+
+	return tree, changed
+}
+
+func (r *redactor) isRedactedKey(key string) bool {
+	return secret.HasName(key, r.keys)
+}
+
+// mentionsKey is a quick check of the whole text for the redacted keys before parsing it,
+// it finds all the keys matched by isRedactedKey and may find more
+func (r *redactor) mentionsKey(text string) bool {
+	text = secret.NormalizeName(text)
+	for _, k := range r.keys {
+		if strings.Contains(text, k) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// maskedObject adds the fields of the masked object in the order of the keys
+type maskedObject map[string]any
+
+func (m maskedObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, k := range slices.Sorted(maps.Keys(m)) {
+		err := enc.AddReflected(k, m[k])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// marshalJSON marshals the value like zap encoders do, without escaping of html characters
+func marshalJSON(v any) ([]byte, error) {
+	buf := bytes.Buffer{}
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+
+	err := enc.Encode(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// hasDigits reports whether s has at least n digits
+func hasDigits(s string, n int) bool {
+	for i := 0; i < len(s) && n > 0; i++ {
+		if s[i] >= '0' && s[i] <= '9' {
+			n--
+		}
+	}
+
+	return n == 0
+}
+
+// isLuhnValid checks the digits of the card number candidate
+func isLuhnValid(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+
+		sum += d
+		double = !double
+	}
+
+	return sum%10 == 0
+}
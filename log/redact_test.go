@@ -0,0 +1,171 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// observeRedacted returns the global logger writing masked entries to the observer,
+// the previous global logger is restored after the test
+func observeRedacted(t *testing.T) *observer.ObservedLogs {
+	t.Helper()
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	t.Cleanup(zap.ReplaceGlobals(zap.New(NewRedactCore(core))))
+
+	return logs
+}
+
+type user struct {
+	Name     string
+	Password string
+}
+
+type tokenObject struct{}
+
+func (tokenObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("name", "bob")
+	enc.AddString("token", "t")
+
+	return nil
+}
+
+func TestRedactFields(t *testing.T) {
+	tests := []struct {
+		name  string
+		field zap.Field
+		want  any
+	}{
+		{name: "sensitive key", field: zap.String("db_password", "p"), want: "[REDACTED]"},
+		{name: "header key", field: zap.String("X-Api-Key", "k"), want: "[REDACTED]"},
+		{name: "camel case key", field: zap.String("refreshTokens", "t"), want: "[REDACTED]"},
+		{name: "key of other words", field: zap.Int("tokens_count", 5), want: int64(5)},
+		{name: "card number", field: zap.String("msg", "card 4111 1111 1111 1111 is used"), want: "card [REDACTED] is used"},
+		{name: "not a card number", field: zap.String("msg", "id 4111 1111 1111 1112"), want: "id 4111 1111 1111 1112"},
+		{name: "card number integer", field: zap.Int64("order_id", 4111111111111111), want: "[REDACTED]"},
+		{name: "not a card number integer", field: zap.Int64("order_id", 4111111111111112), want: int64(4111111111111112)},
+		{name: "card number unsigned", field: zap.Uint64("order_id", 4111111111111111), want: "[REDACTED]"},
+		{name: "json string", field: zap.String("body", `{"name":"bob","password":"p"}`), want: `{"name":"bob","password":"[REDACTED]"}`},
+		{name: "error", field: zap.Error(errors.New("card 4111111111111111")), want: "card [REDACTED]"},
+		{name: "byte string", field: zap.ByteString("raw", []byte("4111111111111111")), want: "[REDACTED]"},
+		{
+			name:  "reflected struct",
+			field: zap.Any("user", user{Name: "bob", Password: "p"}),
+			want:  map[string]any{"Name": "bob", "Password": "[REDACTED]"},
+		},
+		{
+			name:  "object",
+			field: zap.Object("user", tokenObject{}),
+			want:  map[string]any{"name": "bob", "token": "[REDACTED]"},
+		},
+		{
+			name:  "array of objects",
+			field: zap.Objects("users", []tokenObject{{}}),
+			want:  []any{map[string]any{"name": "bob", "token": "[REDACTED]"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := observeRedacted(t)
+
+			zap.L().Info("entry", tt.field)
+
+			got := logs.All()[0].ContextMap()[tt.field.Key]
+			if fmt.Sprint(normalizeValue(got)) != fmt.Sprint(tt.want) {
+				t.Errorf("%s = %#v, want %#v", tt.field.Key, got, tt.want)
+			}
+		})
+	}
+}
+
+// normalizeValue decodes the raw json of the masked reflected fields for comparison
+func normalizeValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, item := range v {
+			v[k] = normalizeValue(item)
+		}
+
+		return v
+	case json.RawMessage:
+		tree := any(nil)
+		_ = json.Unmarshal(v, &tree)
+
+		return tree
+	default:
+		return v
+	}
+}
+
+func TestRedactKeepsUnmaskedFields(t *testing.T) {
+	r := currentRedactor.Load()
+
+	for _, f := range []zap.Field{
+		zap.Any("user", struct{ Name string }{Name: "bob"}),
+		zap.String("name", "bob"),
+		zap.Int64("count", 5),
+		zap.Object("object", zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+			enc.AddString("name", "bob")
+			return nil
+		})),
+	} {
+		if _, ok := r.field(f); ok {
+			t.Errorf("field %s is replaced, want it to be kept", f.Key)
+		}
+	}
+}
+
+func TestSetRedaction(t *testing.T) {
+	t.Cleanup(func() { _ = SetRedaction(RedactionConfig{}) })
+
+	err := SetRedaction(RedactionConfig{Keys: []string{"ssn"}, Patterns: []string{`\d{3}-\d{2}-\d{4}`}})
+	if err != nil {
+		t.Fatalf("SetRedaction() error = %v", err)
+	}
+
+	logs := observeRedacted(t)
+	zap.L().Info("entry", zap.String("user_ssn", "1"), zap.String("msg", "ssn 123-45-6789"), zap.String("password", "p"))
+
+	got := logs.All()[0].ContextMap()
+	if fmt.Sprint(got) != "map[msg:ssn [REDACTED] password:[REDACTED] user_ssn:[REDACTED]]" {
+		t.Errorf("fields = %v, want the custom key and pattern masked", got)
+	}
+
+	err = SetRedaction(RedactionConfig{Patterns: []string{"("}})
+	if err == nil {
+		t.Error("SetRedaction() error = nil, want the invalid pattern error")
+	}
+
+	_ = SetRedaction(RedactionConfig{Disable: true})
+	zap.L().Info("entry", zap.String("password", "p"))
+
+	if got := logs.All()[1].ContextMap()["password"]; got != "p" {
+		t.Errorf("password = %v, want the value as is when redaction is disabled", got)
+	}
+}
+
+func TestRedactSlogGroups(t *testing.T) {
+	logs := observeRedacted(t)
+
+	logger := slog.New(NewSlogHandler("")).WithGroup("request")
+	logger.InfoContext(context.Background(), "entry",
+		slog.String("path", "/login"),
+		slog.Group("auth", slog.String("user", "bob"), slog.String("token", "t")),
+		slog.Any("body", user{Name: "bob", Password: "p"}),
+	)
+
+	got := fmt.Sprint(normalizeValue(logs.All()[0].ContextMap()))
+	want := "map[request:map[auth:map[token:[REDACTED] user:bob] body:map[Name:bob Password:[REDACTED]] path:/login]]"
+	if got != want {
+		t.Errorf("fields = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,194 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const rotatedTimeFormat = "2006-01-02T15-04-05.000"
+
+// rotatingFile writes to the file and renames it to <name>-<time><ext> when it exceeds
+// the size or the interval passes, old rotated files are removed by count and age
+type rotatingFile struct {
+	path string
+	cfg  RotationConfig
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+}
+
+func newRotatingFile(path string, cfg RotationConfig) (*rotatingFile, error) {
+	if path == "" {
+		return nil, fmt.Errorf("log file path is empty")
+	}
+
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &rotatingFile{path: path, cfg: cfg}
+
+	err = f.open()
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	rotateErr := error(nil)
+	if f.needsRotation(len(p)) {
+		rotateErr = f.rotate()
+		if f.file == nil {
+			return 0, rotateErr
+		}
+	}
+
+	// the entry is written even if the rotation failed, the error is reported by zap
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+
+	return n, errors.Join(err, rotateErr)
+}
+
+func (f *rotatingFile) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+
+	return f.file.Sync()
+}
+
+func (f *rotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+
+	err := f.file.Close()
+	f.file = nil
+
+	return err
+}
+
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	f.file, f.size, f.openedAt = file, info.Size(), time.Now()
+
+	return nil
+}
+
+func (f *rotatingFile) needsRotation(n int) bool {
+	maxSize := int64(f.cfg.MaxSize) * 1024 * 1024
+	if maxSize > 0 && f.size > 0 && f.size+int64(n) > maxSize {
+		return true
+	}
+
+	interval := time.Duration(f.cfg.Interval)
+
+	return interval > 0 && time.Since(f.openedAt) >= interval
+}
+
+// rotate renames the file and opens a new one, the original path is reopened
+// if the rotation fails, so that logging goes on
+func (f *rotatingFile) rotate() error {
+	err := f.file.Close()
+	f.file = nil
+
+	if err == nil {
+		ext := filepath.Ext(f.path)
+		rotatedPath := fmt.Sprintf("%s-%s%s", strings.TrimSuffix(f.path, ext), time.Now().Format(rotatedTimeFormat), ext)
+
+		err = os.Rename(f.path, rotatedPath)
+	}
+
+	openErr := f.open()
+	if err != nil || openErr != nil {
+		return errors.Join(err, openErr)
+	}
+
+	f.removeOld()
+
+	return nil
+}
+
+// removeOld removes rotated files beyond MaxBackups and older than MaxAge
+func (f *rotatingFile) removeOld() {
+	if f.cfg.MaxBackups <= 0 && f.cfg.MaxAge <= 0 {
+		return
+	}
+
+	ext := filepath.Ext(f.path)
+	prefix := strings.TrimSuffix(filepath.Base(f.path), ext) + "-"
+
+	entries, err := os.ReadDir(filepath.Dir(f.path))
+	if err != nil {
+		return
+	}
+
+	rotated := []string(nil)
+	for _, e := range entries {
+		name := e.Name()
+		stamp, ok := strings.CutPrefix(name, prefix)
+		if !ok || !strings.HasSuffix(stamp, ext) {
+			continue
+		}
+
+		_, err = time.Parse(rotatedTimeFormat, strings.TrimSuffix(stamp, ext))
+		if err != nil {
+			continue
+		}
+
+		rotated = append(rotated, name)
+	}
+
+	// the time format sorts lexically, the newest files go first
+	slices.Sort(rotated)
+	slices.Reverse(rotated)
+
+	for i, name := range rotated {
+		p := filepath.Join(filepath.Dir(f.path), name)
+
+		remove := f.cfg.MaxBackups > 0 && i >= f.cfg.MaxBackups
+		if !remove && f.cfg.MaxAge > 0 {
+			info, err := os.Stat(p)
+			remove = err == nil && time.Since(info.ModTime()) > time.Duration(f.cfg.MaxAge)
+		}
+
+		if remove {
+			_ = os.Remove(p)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/timmbarton/utils/types/secs"
+)
+
+// rotatedFiles returns the names of the rotated files of the log file
+func rotatedFiles(t *testing.T, path string) []string {
+	t.Helper()
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+
+	names := []string(nil)
+	for _, e := range entries {
+		if e.Name() != filepath.Base(path) && strings.HasPrefix(e.Name(), "app-") {
+			names = append(names, e.Name())
+		}
+	}
+
+	return names
+}
+
+func TestRotatingFileBySize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	f, err := newRotatingFile(path, RotationConfig{MaxSize: 1, MaxBackups: 2})
+	if err != nil {
+		t.Fatalf("newRotatingFile() error = %v", err)
+	}
+	defer f.Close()
+
+	chunk := bytes.Repeat([]byte("x"), 700*1024)
+	for i := 0; i < 5; i++ {
+		_, err = f.Write(chunk)
+		if err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+
+		// rotated files are named by milliseconds
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if got := rotatedFiles(t, path); len(got) != 2 {
+		t.Errorf("rotated files = %v, want 2 backups", got)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if info.Size() != int64(len(chunk)) {
+		t.Errorf("file size = %d, want the last chunk only", info.Size())
+	}
+}
+
+func TestRotatingFileByInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	f, err := newRotatingFile(path, RotationConfig{Interval: secs.Seconds(20 * time.Millisecond)})
+	if err != nil {
+		t.Fatalf("newRotatingFile() error = %v", err)
+	}
+	defer f.Close()
+
+	_, _ = f.Write([]byte("first\n"))
+	time.Sleep(30 * time.Millisecond)
+	_, _ = f.Write([]byte("second\n"))
+
+	if got := rotatedFiles(t, path); len(got) != 1 {
+		t.Errorf("rotated files = %v, want 1", got)
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "second\n" {
+		t.Errorf("file = %q, want the entry after the rotation", data)
+	}
+}
+
+func TestRotatingFileClosed(t *testing.T) {
+	f, err := newRotatingFile(filepath.Join(t.TempDir(), "app.log"), RotationConfig{})
+	if err != nil {
+		t.Fatalf("newRotatingFile() error = %v", err)
+	}
+
+	_ = f.Close()
+
+	_, err = f.Write([]byte("entry\n"))
+	if !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Write() after Close error = %v, want %v", err, os.ErrClosed)
+	}
+}
+
+func TestRotatingFileFailedRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	f, err := newRotatingFile(path, RotationConfig{Interval: secs.Seconds(20 * time.Millisecond)})
+	if err != nil {
+		t.Fatalf("newRotatingFile() error = %v", err)
+	}
+	defer f.Close()
+
+	// the rename fails for the removed file, the entry is still written to the reopened path
+	_ = os.Remove(path)
+	time.Sleep(30 * time.Millisecond)
+
+	n, err := f.Write([]byte("entry\n"))
+	if err == nil || n != len("entry\n") {
+		t.Errorf("Write() = %d, %v, want the entry written and the rotation error", n, err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "entry\n" {
+		t.Errorf("file = %q, want the entry", data)
+	}
+}
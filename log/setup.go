@@ -0,0 +1,306 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/timmbarton/utils/types/secs"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	EncodingConsole = "console"
+	EncodingJSON    = "json"
+
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+	OutputFile   = "file"
+)
+
+// Config of the global logger, zero values mean console info logs to stdout with callers
+type Config struct {
+	Level    string            `validate:"omitempty,oneof=debug info warn error dpanic panic fatal" default:"info"`
+	Levels   map[string]string // levels of named loggers, see SetLevel
+	Encoding string            `validate:"omitempty,oneof=console json" default:"console"`
+	Outputs  []OutputConfig    `validate:"dive"` // stdout by default
+
+	Redaction RedactionConfig
+
+	DisableCaller   bool
+	SlogDefault     bool   // set the SlogHandler as the default slog handler, it also takes the output of the log package
+	StacktraceLevel string `validate:"omitempty,oneof=debug info warn error dpanic panic fatal" default:"panic"`
+}
+
+type OutputConfig struct {
+	Type     string `validate:"required,oneof=stdout stderr file"`
+	Path     string `validate:"required_if=Type file"`
+	Encoding string `validate:"omitempty,oneof=console json"` // Config.Encoding by default
+	Rotation RotationConfig
+}
+
+// RotationConfig of the file output, the file is rotated when any of the limits is reached
+type RotationConfig struct {
+	MaxSize    int          `validate:"min=0"`   // megabytes, 0 disables rotation by size
+	Interval   secs.Seconds `validate:"seconds"` // 0 disables rotation by time
+	MaxBackups int          `validate:"min=0"`   // 0 keeps all rotated files
+	MaxAge     secs.Seconds `validate:"seconds"` // 0 keeps rotated files forever
+}
+
+var setup = struct {
+	mu      sync.Mutex
+	cores   []zapcore.Core // cores of the outputs
+	extra   []extraCore    // cores added by exporters
+	opts    []zap.Option   // options of the config
+	closers []io.Closer
+	done    bool
+}{}
+
+// extraCore is the core of an exporter added by AddCore
+type extraCore struct {
+	name string
+	core zapcore.Core
+	opts []zap.Option
+}
+
+// Setup configures the global logger with the outputs of the config, cores added
+// by AddCore are kept. It may be called again to reconfigure logging.
+func Setup(cfg Config) error {
+	setup.mu.Lock()
+	defer setup.mu.Unlock()
+
+	rootLevel, err := parseLevelOr(cfg.Level, zapcore.InfoLevel)
+	if err != nil {
+		return err
+	}
+
+	namedLevels := make(map[string]zapcore.Level, len(cfg.Levels))
+	for name, s := range cfg.Levels {
+		namedLevels[name], err = parseLevel(s)
+		if err != nil {
+			return fmt.Errorf("level of %s: %w", name, err)
+		}
+	}
+
+	stacktraceLevel, err := parseLevelOr(cfg.StacktraceLevel, zapcore.PanicLevel)
+	if err != nil {
+		return err
+	}
+
+	err = SetRedaction(cfg.Redaction)
+	if err != nil {
+		return err
+	}
+
+	outputs := cfg.Outputs
+	if len(outputs) == 0 {
+		outputs = []OutputConfig{{Type: OutputStdout}}
+	}
+
+	cores, closers := make([]zapcore.Core, 0, len(outputs)), []io.Closer(nil)
+	for _, out := range outputs {
+		core, closer, err := newOutputCore(out, cfg.Encoding)
+		if err != nil {
+			for _, c := range closers {
+				_ = c.Close()
+			}
+
+			return err
+		}
+
+		cores = append(cores, core)
+		if closer != nil {
+			closers = append(closers, closer)
+		}
+	}
+
+	oldClosers := setup.closers
+	setup.cores, setup.closers, setup.done = cores, closers, true
+
+	setup.opts = []zap.Option{zap.AddStacktrace(stacktraceLevel)}
+	if !cfg.DisableCaller {
+		// entries are logged through the wrappers of this package
+		setup.opts = append(setup.opts, zap.AddCaller(), zap.AddCallerSkip(1))
+	}
+
+	levels.setRoot(rootLevel)
+	for name, lvl := range namedLevels {
+		SetLevel(name, lvl, 0)
+	}
+
+	replaceGlobals()
+
+	// loggers derived before write through outputsCore to the new outputs,
+	// so nothing writes to the files of the previous outputs anymore
+	for _, c := range oldClosers {
+		_ = c.Close()
+	}
+
+	if cfg.SlogDefault {
+		slog.SetDefault(slog.New(NewSlogHandler(RootLogger)))
+	}
+
+	return nil
+}
+
+// AddCore adds the core of an exporter to the global logger, e.g. the SigNoz core.
+// The core and opts added before with the same name are replaced. The default outputs
+// are set up if Setup has not been called. The opts are applied to the global logger
+// and the new global logger is returned.
+func AddCore(name string, core zapcore.Core, opts ...zap.Option) (*zap.Logger, error) {
+	setup.mu.Lock()
+	done := setup.done
+	setup.mu.Unlock()
+
+	if !done {
+		err := Setup(Config{})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	setup.mu.Lock()
+	defer setup.mu.Unlock()
+
+	setup.extra = slices.DeleteFunc(setup.extra, func(c extraCore) bool { return c.name == name })
+	setup.extra = append(setup.extra, extraCore{name: name, core: core, opts: opts})
+
+	return replaceGlobals(), nil
+}
+
+// replaceGlobals builds the global logger from the cores, it is called under setup.mu
+func replaceGlobals() *zap.Logger {
+	cores := make([]zapcore.Core, 0, len(setup.cores)+len(setup.extra))
+	opts := slices.Clone(setup.opts)
+	for _, c := range setup.cores {
+		// values are masked before they reach any output or exporter
+		cores = append(cores, NewRedactCore(c))
+	}
+	for _, c := range setup.extra {
+		cores = append(cores, NewRedactCore(c.core))
+		opts = append(opts, c.opts...)
+	}
+
+	currentOutputs.Store(&outputsVersion{
+		version: currentOutputs.Load().version + 1,
+		core:    zapcore.NewTee(cores...),
+	})
+
+	l := zap.New(NewLevelCore(&outputsCore{}), opts...)
+	zap.ReplaceGlobals(l)
+
+	return l
+}
+
+// outputsVersion is the tee of the cores built by replaceGlobals
+type outputsVersion struct {
+	version uint64
+	core    zapcore.Core
+}
+
+var currentOutputs atomic.Pointer[outputsVersion]
+
+func init() {
+	currentOutputs.Store(&outputsVersion{core: zapcore.NewNopCore()})
+}
+
+// outputsCore writes to the current outputs, so that loggers derived from the global logger,
+// e.g. by Named or With, follow the outputs of later Setup and AddCore calls
+type outputsCore struct {
+	fields []zapcore.Field // added by With
+	cached atomic.Pointer[outputsVersion]
+}
+
+// core returns the current outputs with the fields of the core
+func (c *outputsCore) core() zapcore.Core {
+	current := currentOutputs.Load()
+	if len(c.fields) == 0 {
+		return current.core
+	}
+
+	cached := c.cached.Load()
+	if cached != nil && cached.version == current.version {
+		return cached.core
+	}
+
+	cached = &outputsVersion{version: current.version, core: current.core.With(c.fields)}
+	c.cached.Store(cached)
+
+	return cached.core
+}
+
+func (c *outputsCore) Enabled(lvl zapcore.Level) bool { return c.core().Enabled(lvl) }
+
+func (c *outputsCore) With(fields []zapcore.Field) zapcore.Core {
+	return &outputsCore{fields: slices.Concat(c.fields, fields)}
+}
+
+func (c *outputsCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return c.core().Check(ent, ce)
+}
+
+func (c *outputsCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.core().Write(ent, fields)
+}
+
+func (c *outputsCore) Sync() error { return c.core().Sync() }
+
+func newOutputCore(out OutputConfig, encoding string) (zapcore.Core, io.Closer, error) {
+	if out.Encoding != "" {
+		encoding = out.Encoding
+	}
+
+	encoder, err := newEncoder(encoding)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ws, closer := zapcore.WriteSyncer(nil), io.Closer(nil)
+	switch out.Type {
+	case OutputStdout, "":
+		ws = zapcore.Lock(os.Stdout)
+	case OutputStderr:
+		ws = zapcore.Lock(os.Stderr)
+	case OutputFile:
+		f, err := newRotatingFile(out.Path, out.Rotation)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		ws, closer = f, f
+	default:
+		return nil, nil, fmt.Errorf("unknown log output %q", out.Type)
+	}
+
+	return zapcore.NewCore(encoder, ws, LevelEnabler()), closer, nil
+}
+
+func newEncoder(encoding string) (zapcore.Encoder, error) {
+	switch encoding {
+	case EncodingConsole, "":
+		encoderCfg := zap.NewDevelopmentEncoderConfig()
+		encoderCfg.EncodeTime = zapcore.ISO8601TimeEncoder
+
+		return zapcore.NewConsoleEncoder(encoderCfg), nil
+	case EncodingJSON:
+		encoderCfg := zap.NewProductionEncoderConfig()
+		encoderCfg.EncodeTime = zapcore.ISO8601TimeEncoder
+
+		return zapcore.NewJSONEncoder(encoderCfg), nil
+	default:
+		return nil, fmt.Errorf("unknown log encoding %q", encoding)
+	}
+}
+
+func parseLevelOr(s string, def zapcore.Level) (zapcore.Level, error) {
+	if s == "" {
+		return def, nil
+	}
+
+	return parseLevel(s)
+}
@@ -0,0 +1,61 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// setupFile configures the global logger to write json entries to the file
+func setupFile(t *testing.T, path string) {
+	t.Helper()
+
+	err := Setup(Config{
+		Encoding:      EncodingJSON,
+		Outputs:       []OutputConfig{{Type: OutputFile, Path: path}},
+		DisableCaller: true,
+	})
+	if err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+
+	t.Cleanup(func() { _ = Setup(Config{}) })
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+
+	return string(data)
+}
+
+func TestSetupAgainKeepsDerivedLoggers(t *testing.T) {
+	dir := t.TempDir()
+	first, second := filepath.Join(dir, "first.log"), filepath.Join(dir, "second.log")
+
+	setupFile(t, first)
+
+	derived := zap.L().Named("worker").With(zap.String("id", "1"))
+	derived.Info("before")
+
+	setupFile(t, second)
+
+	derived.Info("after")
+	_ = Sync()
+
+	if got := readFile(t, first); !strings.Contains(got, `"before"`) || strings.Contains(got, `"after"`) {
+		t.Errorf("first output = %s, want only the entry before the second Setup", got)
+	}
+
+	got := readFile(t, second)
+	if !strings.Contains(got, `"msg":"after"`) || !strings.Contains(got, `"logger":"worker"`) || !strings.Contains(got, `"id":"1"`) {
+		t.Errorf("second output = %s, want the entry of the derived logger with its name and fields", got)
+	}
+}
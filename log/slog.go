@@ -0,0 +1,163 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SlogHandler is the slog.Handler writing to the current global zap logger,
+// so that logs of libraries using log/slog get the same outputs, levels and context fields
+type SlogHandler struct {
+	name   string
+	fields []zap.Field // attrs and namespaces of groups added by WithAttrs and WithGroup
+}
+
+// NewSlogHandler returns the handler logging with the logger name, empty name means the root logger
+func NewSlogHandler(name string) *SlogHandler {
+	return &SlogHandler{name: name}
+}
+
+func (h *SlogHandler) logger() *zap.Logger {
+	if h.name == "" {
+		return zap.L()
+	}
+
+	return zap.L().Named(h.name)
+}
+
+func (h *SlogHandler) Enabled(_ context.Context, lvl slog.Level) bool {
+	return h.logger().Core().Enabled(zapLevel(lvl)) && levels.enabled(h.name, zapLevel(lvl))
+}
+
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	ce := h.logger().Check(zapLevel(r.Level), r.Message)
+	if ce == nil {
+		return nil
+	}
+
+	if !r.Time.IsZero() {
+		ce.Time = r.Time
+	}
+
+	// the caller of the slog function, not of this handler, it is unknown without the pc
+	withCaller := ce.Caller.Defined
+	ce.Caller = zapcore.EntryCaller{}
+	if withCaller && r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		ce.Caller = zapcore.NewEntryCaller(frame.PC, frame.File, frame.Line, true)
+		ce.Caller.Function = frame.Function
+	}
+
+	// context fields go first, so that they are outside of the groups
+	fields := contextFields(ctx, nil)
+	fields = append(fields, h.fields...)
+	r.Attrs(func(a slog.Attr) bool {
+		fields = appendAttr(fields, a)
+		return true
+	})
+
+	ce.Write(fields...)
+
+	return nil
+}
+
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := appendAttrs(append([]zap.Field(nil), h.fields...), attrs)
+
+	return &SlogHandler{name: h.name, fields: fields}
+}
+
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	fields := append(append([]zap.Field(nil), h.fields...), zap.Namespace(name))
+
+	return &SlogHandler{name: h.name, fields: fields}
+}
+
+// zapLevel maps slog levels to the nearest lower zap level
+func zapLevel(lvl slog.Level) zapcore.Level {
+	switch {
+	case lvl >= slog.LevelError:
+		return zapcore.ErrorLevel
+	case lvl >= slog.LevelWarn:
+		return zapcore.WarnLevel
+	case lvl >= slog.LevelInfo:
+		return zapcore.InfoLevel
+	default:
+		return zapcore.DebugLevel
+	}
+}
+
+func appendAttr(fields []zap.Field, a slog.Attr) []zap.Field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		group := a.Value.Group()
+		if len(group) == 0 {
+			return fields
+		}
+
+		// attrs of the group with the empty key are inlined
+		if a.Key == "" {
+			return appendAttrs(fields, group)
+		}
+
+		return append(fields, zap.Object(a.Key, groupMarshaler(group)))
+	}
+
+	return append(fields, attrField(a))
+}
+
+func attrField(a slog.Attr) zap.Field {
+	v := a.Value
+	switch v.Kind() {
+	case slog.KindString:
+		return zap.String(a.Key, v.String())
+	case slog.KindInt64:
+		return zap.Int64(a.Key, v.Int64())
+	case slog.KindUint64:
+		return zap.Uint64(a.Key, v.Uint64())
+	case slog.KindFloat64:
+		return zap.Float64(a.Key, v.Float64())
+	case slog.KindBool:
+		return zap.Bool(a.Key, v.Bool())
+	case slog.KindDuration:
+		return zap.Duration(a.Key, v.Duration())
+	case slog.KindTime:
+		return zap.Time(a.Key, v.Time())
+	default:
+		if err, ok := v.Any().(error); ok {
+			return zap.NamedError(a.Key, err)
+		}
+
+		return zap.Any(a.Key, v.Any())
+	}
+}
+
+type groupMarshaler []slog.Attr
+
+func (g groupMarshaler) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, f := range appendAttrs(nil, g) {
+		f.AddTo(enc)
+	}
+
+	return nil
+}
+
+func appendAttrs(fields []zap.Field, attrs []slog.Attr) []zap.Field {
+	for _, a := range attrs {
+		fields = appendAttr(fields, a)
+	}
+
+	return fields
+}
@@ -8,7 +8,8 @@ import (
 )
 
 type WrappedLogger struct {
-	l *zap.Logger
+	l     *zap.Logger
+	bound *ctxFields // context fields which are already added to l, see FromContext
 }
 
 func NewWrappedLogger(l *zap.Logger) *WrappedLogger {
@@ -20,58 +21,42 @@ func NewWrappedLogger(l *zap.Logger) *WrappedLogger {
 }
 
 func (w *WrappedLogger) Log(ctx context.Context, lvl zapcore.Level, msg string, fields ...zap.Field) {
-	if ctx != nil {
-		fields = append(fields, zap.Any(CtxKey, ctx))
-	}
+	fields = append(fields, contextFields(ctx, w.bound)...)
 
 	w.l.Log(lvl, msg, fields...)
 }
 func (w *WrappedLogger) Debug(ctx context.Context, msg string, fields ...zap.Field) {
-	if ctx != nil {
-		fields = append(fields, zap.Any(CtxKey, ctx))
-	}
+	fields = append(fields, contextFields(ctx, w.bound)...)
 
 	w.l.Debug(msg, fields...)
 }
 func (w *WrappedLogger) Info(ctx context.Context, msg string, fields ...zap.Field) {
-	if ctx != nil {
-		fields = append(fields, zap.Any(CtxKey, ctx))
-	}
+	fields = append(fields, contextFields(ctx, w.bound)...)
 
 	w.l.Info(msg, fields...)
 }
 func (w *WrappedLogger) Warn(ctx context.Context, msg string, fields ...zap.Field) {
-	if ctx != nil {
-		fields = append(fields, zap.Any(CtxKey, ctx))
-	}
+	fields = append(fields, contextFields(ctx, w.bound)...)
 
 	w.l.Warn(msg, fields...)
 }
 func (w *WrappedLogger) Error(ctx context.Context, msg string, fields ...zap.Field) {
-	if ctx != nil {
-		fields = append(fields, zap.Any(CtxKey, ctx))
-	}
+	fields = append(fields, contextFields(ctx, w.bound)...)
 
 	w.l.Error(msg, fields...)
 }
 func (w *WrappedLogger) DPanic(ctx context.Context, msg string, fields ...zap.Field) {
-	if ctx != nil {
-		fields = append(fields, zap.Any(CtxKey, ctx))
-	}
+	fields = append(fields, contextFields(ctx, w.bound)...)
 
 	w.l.DPanic(msg, fields...)
 }
 func (w *WrappedLogger) Panic(ctx context.Context, msg string, fields ...zap.Field) {
-	if ctx != nil {
-		fields = append(fields, zap.Any(CtxKey, ctx))
-	}
+	fields = append(fields, contextFields(ctx, w.bound)...)
 
 	w.l.Panic(msg, fields...)
 }
 func (w *WrappedLogger) Fatal(ctx context.Context, msg string, fields ...zap.Field) {
-	if ctx != nil {
-		fields = append(fields, zap.Any(CtxKey, ctx))
-	}
+	fields = append(fields, contextFields(ctx, w.bound)...)
 
 	w.l.Fatal(msg, fields...)
 }
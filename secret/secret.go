@@ -0,0 +1,114 @@
+package secret
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode"
+)
+
+// Redacted replaces the masked values in fmt output, config dumps and logs
+const Redacted = "[REDACTED]"
+
+// Secret is a string which decodes normally but never shows its value
+// in fmt, json or zap fields, use Value to get the actual value
+type Secret string
+
+func (s Secret) Value() string    { return string(s) }
+func (s Secret) String() string   { return Redacted }
+func (s Secret) GoString() string { return Redacted }
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(Redacted)
+}
+
+// SensitiveNames are the names of fields and keys which values are masked,
+// names are compared by HasName
+var SensitiveNames = []string{
+	"password", "passwd", "secret", "token", "credential", "authorization", "cookie", "apikey", "cardnumber", "cvv",
+}
+
+// IsSensitiveName reports whether the name has any of SensitiveNames, see HasName
+func IsSensitiveName(name string) bool {
+	return HasName(name, SensitiveNames)
+}
+
+// HasName reports whether consecutive words of the name form one of the names given
+// in the NormalizeName form. Words are split by separators and case changes, the last word
+// may be plural: "db_password", "X-Api-Key" and "refreshTokens" have "password", "apikey"
+// and "token", "tokens_count" has none of them.
+func HasName(name string, names []string) bool {
+	words := splitWords(name)
+
+	for i := range words {
+		run := ""
+		for j := i; j < len(words); j++ {
+			run += words[j]
+			last := j == len(words)-1
+
+			for _, n := range names {
+				if run == n || last && run == n+"s" {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// splitWords splits the name into lower-case words by non-alphanumeric characters,
+// lower to upper case changes, acronyms and digits: "APIKey2_ttl" is "api", "key", "2", "ttl"
+func splitWords(name string) []string {
+	runes := []rune(name)
+	words := []string(nil)
+	start := -1
+
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if start >= 0 {
+				words = append(words, strings.ToLower(string(runes[start:i])))
+				start = -1
+			}
+
+			continue
+		}
+
+		if start >= 0 && isWordStart(runes, i) {
+			words = append(words, strings.ToLower(string(runes[start:i])))
+			start = -1
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+
+	if start >= 0 {
+		words = append(words, strings.ToLower(string(runes[start:])))
+	}
+
+	return words
+}
+
+// isWordStart reports whether the letter or digit at i starts a new word after the previous one
+func isWordStart(runes []rune, i int) bool {
+	prev, r := runes[i-1], runes[i]
+
+	switch {
+	case unicode.IsDigit(prev) != unicode.IsDigit(r):
+		return true
+	case unicode.IsLower(prev) && unicode.IsUpper(r):
+		return true
+	case unicode.IsUpper(prev) && unicode.IsUpper(r):
+		// the last capital of an acronym starts the next word: "APIKey"
+		return i+1 < len(runes) && unicode.IsLower(runes[i+1])
+	default:
+		return false
+	}
+}
+
+var nameSeparators = strings.NewReplacer("_", "", "-", "")
+
+// NormalizeName lowercases the name and drops '-' and '_', so that "API_KEY" matches "apikey"
+func NormalizeName(name string) string {
+	return strings.ToLower(nameSeparators.Replace(name))
+}
@@ -6,6 +6,8 @@ import (
 	"log"
 	"time"
 
+	"github.com/timmbarton/utils/types/secs"
+
 	"github.com/timmbarton/layout/lifecycle"
 )
 
@@ -19,6 +21,11 @@ const (
 	DefaultStopTimeout  = 30 * time.Second
 )
 
+type Config struct {
+	StartTimeout secs.Seconds `validate:"seconds" default:"30s"`
+	StopTimeout  secs.Seconds `validate:"seconds" default:"30s"`
+}
+
 type App struct {
 	components []lifecycle.Lifecycle
 
@@ -26,12 +33,18 @@ type App struct {
 	stopTimeout  time.Duration
 }
 
+func (a *App) Init(cfg Config) {
+	a.startTimeout = time.Duration(cfg.StartTimeout)
+	a.stopTimeout = time.Duration(cfg.StopTimeout)
+}
+
 func (a *App) AddComponents(components ...lifecycle.Lifecycle) {
 	a.components = append(a.components, components...)
 }
 
 func (a *App) Start(ctx context.Context) error {
 	log.Println("starting app")
+	a.logStartupSummary(ctx)
 
 	okCh, errCh := make(chan any), make(chan error)
 
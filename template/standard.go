@@ -0,0 +1,172 @@
+package template
+
+import (
+	"github.com/go-redis/redis/v8"
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/timmbarton/layout/components/adminserver"
+	"github.com/timmbarton/layout/components/grpcserver"
+	"github.com/timmbarton/layout/components/httpserver"
+	"github.com/timmbarton/layout/components/jaeger"
+	"github.com/timmbarton/layout/components/pidfile"
+	"github.com/timmbarton/layout/components/postgresconn"
+	"github.com/timmbarton/layout/components/redisconn"
+	"github.com/timmbarton/layout/components/signoz"
+	"github.com/timmbarton/layout/lifecycle"
+	layoutlog "github.com/timmbarton/layout/log"
+)
+
+// StandardConfig is the top-level config of a typical service.
+// Components are enabled by their sections, nil sections are skipped by the Builder.
+type StandardConfig struct {
+	App      Config
+	Log      layoutlog.Config
+	PidFile  *pidfile.Config
+	Signoz   *signoz.Config
+	Jaeger   *jaeger.Config
+	Postgres *postgresconn.Config
+	Redis    *redisconn.Config
+	Admin    *adminserver.Config
+	GRPC     *grpcserver.DefaultServerConfig
+	HTTP     *httpserver.Config
+}
+
+// Builder creates the enabled built-in components and registers them in the App:
+// logging, pid file, telemetry, connections, custom components and servers.
+// Servers start last and stop first, so that they don't serve requests without connections.
+type Builder struct {
+	cfg        StandardConfig
+	components []lifecycle.Lifecycle
+	bindHTTP   func(s *Standard, r fiber.Router)
+}
+
+func NewBuilder(cfg StandardConfig) *Builder {
+	return &Builder{cfg: cfg}
+}
+
+// WithComponents adds custom components which are started after the connections and before the servers
+func (b *Builder) WithComponents(components ...lifecycle.Lifecycle) *Builder {
+	b.components = append(b.components, components...)
+	return b
+}
+
+// WithHTTPRoutes sets the routes binding of the HTTP server, the connections are created
+// but not yet started when bind is called
+func (b *Builder) WithHTTPRoutes(bind func(s *Standard, r fiber.Router)) *Builder {
+	b.bindHTTP = bind
+	return b
+}
+
+// Build creates the components. GRPC services are registered with
+// Standard.GRPCServer().RegisterService before starting the App.
+func (b *Builder) Build() (s *Standard, err error) {
+	err = layoutlog.Setup(b.cfg.Log)
+	if err != nil {
+		return nil, err
+	}
+
+	s = &Standard{app: new(App)}
+	s.app.Init(b.cfg.App)
+
+	if b.cfg.PidFile != nil {
+		s.pidFile = pidfile.New(*b.cfg.PidFile)
+		s.app.AddComponents(s.pidFile)
+	}
+
+	if b.cfg.Signoz != nil {
+		s.signoz, err = signoz.New(*b.cfg.Signoz)
+		if err != nil {
+			return nil, err
+		}
+
+		s.app.AddComponents(s.signoz)
+	}
+
+	if b.cfg.Jaeger != nil {
+		s.jaeger = jaeger.New(*b.cfg.Jaeger)
+		s.app.AddComponents(s.jaeger)
+	}
+
+	if b.cfg.Postgres != nil {
+		s.postgres, err = postgresconn.New(*b.cfg.Postgres)
+		if err != nil {
+			return nil, err
+		}
+
+		s.app.AddComponents(s.postgres)
+	}
+
+	if b.cfg.Redis != nil {
+		s.redis, err = redisconn.New(*b.cfg.Redis)
+		if err != nil {
+			return nil, err
+		}
+
+		s.app.AddComponents(s.redis)
+	}
+
+	s.app.AddComponents(b.components...)
+
+	if b.cfg.Admin != nil {
+		s.admin = adminserver.New(*b.cfg.Admin)
+		s.app.AddComponents(s.admin)
+	}
+
+	if b.cfg.GRPC != nil {
+		s.grpc = new(grpcserver.DefaultServer)
+		s.grpc.Init(*b.cfg.GRPC)
+		s.app.AddComponents(s.grpc)
+	}
+
+	if b.cfg.HTTP != nil {
+		s.http = new(httpserver.DefaultServer)
+		s.http.Init(*b.cfg.HTTP, func(r fiber.Router) {
+			if b.bindHTTP != nil {
+				b.bindHTTP(s, r)
+			}
+		})
+		s.app.AddComponents(s.http)
+	}
+
+	return s, nil
+}
+
+// Standard is the App assembled by the Builder, accessors of disabled components return nil
+type Standard struct {
+	app *App
+
+	pidFile  *pidfile.PidFile
+	signoz   *signoz.Connector
+	jaeger   *jaeger.Tracer
+	postgres *postgresconn.Conn
+	redis    *redisconn.Conn
+	admin    *adminserver.Server
+	grpc     *grpcserver.DefaultServer
+	http     *httpserver.DefaultServer
+}
+
+func (s *Standard) App() *App                             { return s.app }
+func (s *Standard) Signoz() *signoz.Connector             { return s.signoz }
+func (s *Standard) Jaeger() *jaeger.Tracer                { return s.jaeger }
+func (s *Standard) AdminServer() *adminserver.Server      { return s.admin }
+func (s *Standard) GRPCServer() *grpcserver.DefaultServer { return s.grpc }
+func (s *Standard) HTTPServer() *httpserver.DefaultServer { return s.http }
+
+// DB returns the Postgres connection, it is usable after the App is started
+func (s *Standard) DB() *sqlx.DB {
+	if s.postgres == nil {
+		return nil
+	}
+
+	return s.postgres.DB()
+}
+
+// Redis returns the Redis client, it is usable after the App is started
+func (s *Standard) Redis() *redis.Client {
+	if s.redis == nil {
+		return nil
+	}
+
+	return s.redis.Client()
+}
@@ -0,0 +1,60 @@
+package template
+
+import (
+	"context"
+	"runtime"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/timmbarton/layout/buildinfo"
+	"github.com/timmbarton/layout/lifecycle"
+	layoutlog "github.com/timmbarton/layout/log"
+)
+
+// logStartupSummary logs build and runtime information and registered components as one entry
+func (a *App) logStartupSummary(ctx context.Context) {
+	bi := buildinfo.Get()
+
+	layoutlog.Named("App").Info(
+		ctx,
+		"startup summary",
+		zap.Dict(
+			"build",
+			zap.String("module", bi.Path),
+			zap.String("version", bi.Version),
+			zap.String("revision", bi.Revision),
+			zap.String("time", bi.Time),
+			zap.Bool("modified", bi.Modified),
+			zap.String("go", bi.GoVersion),
+		),
+		zap.Dict(
+			"runtime",
+			zap.Int("gomaxprocs", runtime.GOMAXPROCS(0)),
+			zap.Int("numcpu", runtime.NumCPU()),
+		),
+		zap.String("hostname", buildinfo.Hostname()),
+		zap.String("env", buildinfo.Env()),
+		zap.Array("components", componentsMarshaler(a.components)),
+	)
+}
+
+type componentsMarshaler []lifecycle.Lifecycle
+
+func (cs componentsMarshaler) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, c := range cs {
+		err := enc.AppendObject(zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+			enc.AddString("name", c.GetName())
+			if addressable, ok := c.(lifecycle.Addressable); ok {
+				enc.AddString("addr", addressable.GetAddr())
+			}
+
+			return nil
+		}))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}